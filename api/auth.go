@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"Abby/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey gin context 中保存調用者的鍵
+const principalKey = "auth.principal"
+
+// Authenticator 驗證 API 密鑰和 JWT，並按路由組要求權限
+// nil 表示不啟用認證，所有請求都被允許
+type Authenticator struct {
+	keys *auth.KeyStore
+	jwt  *auth.JWTVerifier
+	// anonymous 不帶憑據的請求擁有的權限
+	anonymous []string
+}
+
+// NewAuthenticator 創建認證器，keys 或 jwt 為 nil 時不接受對應的憑據
+func NewAuthenticator(keys *auth.KeyStore, jwt *auth.JWTVerifier, anonymous []string) *Authenticator {
+	return &Authenticator{keys: keys, jwt: jwt, anonymous: anonymous}
+}
+
+// Require 返回要求 scope 權限的中間件
+// 憑據從 Authorization: Bearer <API 密鑰或 JWT> 或 X-API-Key 讀取，無效的憑據返回 401，權限不足返回 403
+func (a *Authenticator) Require(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Next()
+			return
+		}
+
+		principal, err := a.authenticate(c)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="abby"`)
+			abortWithProblem(c, newProblem(CodeUnauthorized, err.Error()))
+			return
+		}
+		if !auth.HasScope(principal.Scopes, scope) {
+			if principal.Kind == auth.KindAnonymous {
+				c.Header("WWW-Authenticate", `Bearer realm="abby", scope="`+scope+`"`)
+				abortWithProblem(c, newProblem(CodeUnauthorized, "authentication required: "+scope+" scope"))
+				return
+			}
+			abortWithProblem(c, newProblem(CodeForbidden, "missing "+scope+" scope"))
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// authenticate 驗證請求帶的憑據，沒有憑據時返回匿名調用者
+func (a *Authenticator) authenticate(c *gin.Context) (auth.Principal, error) {
+	token := c.GetHeader("X-API-Key")
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, credentials, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return auth.Principal{}, errors.New("unsupported authorization scheme, expected Bearer")
+		}
+		token = strings.TrimSpace(credentials)
+	}
+	if token == "" {
+		return auth.Principal{Kind: auth.KindAnonymous, Scopes: a.anonymous}, nil
+	}
+
+	if strings.HasPrefix(token, auth.KeyPrefix) {
+		if a.keys == nil {
+			return auth.Principal{}, auth.ErrInvalidKey
+		}
+		key, err := a.keys.Verify(token)
+		if err != nil {
+			return auth.Principal{}, err
+		}
+		return auth.Principal{Kind: auth.KindAPIKey, ID: key.ID, Name: key.Name, Scopes: key.Scopes}, nil
+	}
+
+	if a.jwt == nil {
+		return auth.Principal{}, auth.ErrInvalidToken
+	}
+	claims, err := a.jwt.Verify(token)
+	if err != nil {
+		log.Printf("Rejected bearer token: %v", err)
+		return auth.Principal{}, auth.ErrInvalidToken
+	}
+	return auth.Principal{Kind: auth.KindJWT, ID: claims.Subject, Name: claims.Subject, Scopes: claims.Scopes()}, nil
+}
+
+// PrincipalFrom 返回通過認證的調用者，不啟用認證時返回 false
+func PrincipalFrom(c *gin.Context) (auth.Principal, bool) {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	principal, ok := v.(auth.Principal)
+	return principal, ok
+}
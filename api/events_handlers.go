@@ -0,0 +1,329 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Abby/indexer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// 串流連接的心跳間隔，避免代理因為閒置而斷開連接
+	streamHeartbeat = 15 * time.Second
+	// WebSocket 寫入超時
+	wsWriteTimeout = 10 * time.Second
+	// 補發歷史事件時每次從索引讀取的數量
+	replayBatch = 500
+)
+
+// errSubscriptionDropped 客戶端處理太慢，訂閱被索引器移除
+var errSubscriptionDropped = errors.New("subscriber too slow, reconnect with Last-Event-ID")
+
+var upgrader = websocket.Upgrader{
+	// 升級前已由 EventsHandler.allowOrigin 檢查來源，拒絕時返回 problem+json
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// AnyOrigin 允許所有網頁來源連接 WebSocket
+const AnyOrigin = "*"
+
+type EventsHandler struct {
+	indexer *indexer.Indexer
+	// allowedOrigins 除同源頁面外允許連接 WebSocket 的網頁來源
+	allowedOrigins []string
+}
+
+// NewEventsHandler 創建事件串流 handler，indexer 為 nil 時表示未啟用事件索引
+// 瀏覽器會為跨域 WebSocket 帶上 cookie 等憑據，只有同源頁面和 allowedOrigins 中的來源可以連接
+// allowedOrigins 包含 AnyOrigin 時不檢查來源
+func NewEventsHandler(eventIndexer *indexer.Indexer, allowedOrigins []string) *EventsHandler {
+	return &EventsHandler{
+		indexer:        eventIndexer,
+		allowedOrigins: allowedOrigins,
+	}
+}
+
+// allowOrigin 不帶 Origin 頭的非瀏覽器客戶端、同源頁面和允許的來源可以連接
+func (h *EventsHandler) allowOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.allowedOrigins {
+		if allowed == AnyOrigin || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamMessage WebSocket 推送的消息，SSE 的 data 欄位分別為 event 或 {"rolledBackTo": N}
+type StreamMessage struct {
+	// Type stored 表示新事件，reorg 表示高於 rolledBackTo 的事件因鏈重組失效
+	Type         string         `json:"type" enums:"stored,reorg" example:"stored"`
+	ID           string         `json:"id,omitempty" example:"00000000004fdf8700000000"`
+	Event        *indexer.Event `json:"event,omitempty"`
+	RolledBackTo *uint64        `json:"rolledBackTo,omitempty"`
+}
+
+// resumePoint 客戶端要求從哪裡開始補發事件
+type resumePoint struct {
+	lastEventID string
+	fromBlock   *uint64
+}
+
+// eventSink 串流輸出的目標 (SSE 或 WebSocket)
+type eventSink interface {
+	send(msg indexer.Message) error
+	ping() error
+}
+
+// Stream godoc
+// @Summary 訂閱新的值 (SSE)
+// @Description 以 Server-Sent Events 推送每個新的 DataStored 事件。事件 ID 可通過 Last-Event-ID 頭或 lastEventId 參數在重新連接時補發錯過的事件，也可用 fromBlock 從指定區塊開始補發。鏈重組時推送 reorg 事件
+// @Tags storage
+// @Produce text/event-stream
+// @Produce application/problem+json
+// @Param Last-Event-ID header string false "上次收到的事件 ID"
+// @Param lastEventId query string false "上次收到的事件 ID (瀏覽器 EventSource 無法設置請求頭時使用)"
+// @Param fromBlock query int false "從該區塊開始補發事件"
+// @Success 200 {string} string "事件流"
+// @Failure 400 {object} Problem "參數錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 storage:read 權限"
+// @Failure 429 {object} Problem "超過該路由的請求速率，Retry-After 為需要等待的秒數"
+// @Failure 503 {object} Problem "未啟用事件索引 (indexing_disabled)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /storage/events [get]
+func (h *EventsHandler) Stream(c *gin.Context) {
+	from, ok := h.prepare(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	sink := &sseSink{w: c.Writer}
+	if err := h.stream(c.Request.Context(), from, sink); errors.Is(err, errSubscriptionDropped) {
+		fmt.Fprintf(c.Writer, "event: error\ndata: %q\n\n", err.Error())
+		c.Writer.Flush()
+	}
+}
+
+// StreamWebSocket godoc
+// @Summary 訂閱新的值 (WebSocket)
+// @Description 與 /storage/events 相同的事件流，每條消息為一個 StreamMessage JSON。用 lastEventId 或 fromBlock 參數補發錯過的事件
+// @Tags storage
+// @Param lastEventId query string false "上次收到的事件 ID"
+// @Param fromBlock query int false "從該區塊開始補發事件"
+// @Success 101 {object} StreamMessage "升級為 WebSocket，之後推送 StreamMessage"
+// @Failure 400 {object} Problem "參數錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 storage:read 權限，或網頁來源不在 auth.allowedOrigins 中"
+// @Failure 429 {object} Problem "超過該路由的請求速率，Retry-After 為需要等待的秒數"
+// @Failure 503 {object} Problem "未啟用事件索引 (indexing_disabled)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /storage/events/ws [get]
+func (h *EventsHandler) StreamWebSocket(c *gin.Context) {
+	if !h.allowOrigin(c.Request) {
+		abortWithProblem(c, newProblem(CodeForbidden, fmt.Sprintf("origin %s is not allowed", c.GetHeader("Origin"))))
+		return
+	}
+	from, ok := h.prepare(c)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 已經返回了錯誤響應
+		return
+	}
+	defer conn.Close()
+
+	// 讀取客戶端消息以處理 ping/pong 和關閉幀，客戶端斷開時結束串流
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = h.stream(ctx, from, &wsSink{conn: conn})
+	closeCode, reason := websocket.CloseNormalClosure, ""
+	if errors.Is(err, errSubscriptionDropped) {
+		closeCode, reason = websocket.CloseTryAgainLater, err.Error()
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(wsWriteTimeout))
+}
+
+// prepare 檢查索引是否啟用並解析補發參數，失敗時直接返回錯誤響應
+func (h *EventsHandler) prepare(c *gin.Context) (resumePoint, bool) {
+	var from resumePoint
+	if h.indexer == nil {
+		abortWithProblem(c, newProblem(CodeIndexingDisabled, "Event indexing is disabled"))
+		return from, false
+	}
+
+	from.lastEventID = c.GetHeader("Last-Event-ID")
+	if from.lastEventID == "" {
+		from.lastEventID = c.Query("lastEventId")
+	}
+	if from.lastEventID != "" {
+		if !indexer.ValidEventID(from.lastEventID) {
+			abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid event id"))
+			return from, false
+		}
+	}
+
+	if v := c.Query("fromBlock"); v != "" {
+		block, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid fromBlock"))
+			return from, false
+		}
+		from.fromBlock = &block
+	}
+	return from, true
+}
+
+// stream 先從索引補發 from 之後的事件，再推送新事件，直到客戶端斷開
+// 所有連接共用索引器的同一個事件來源，不會為每個客戶端單獨訂閱鏈上事件
+func (h *EventsHandler) stream(ctx context.Context, from resumePoint, sink eventSink) error {
+	// 先訂閱再補發，避免補發期間產生的新事件丟失
+	sub := h.indexer.Subscribe()
+	defer sub.Close()
+
+	var last *indexer.Event
+	if from.lastEventID != "" || from.fromBlock != nil {
+		query := indexer.Query{
+			Cursor:    from.lastEventID,
+			FromBlock: from.fromBlock,
+			Limit:     replayBatch,
+		}
+		for {
+			page, err := h.indexer.Store().Events(query)
+			if err != nil {
+				return err
+			}
+			for i := range page.Events {
+				if err := sink.send(indexer.Message{Type: indexer.MessageStored, Event: &page.Events[i]}); err != nil {
+					return err
+				}
+				last = &page.Events[i]
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if err := sink.ping(); err != nil {
+				return err
+			}
+		case msg, ok := <-sub.C():
+			if !ok {
+				return errSubscriptionDropped
+			}
+			switch msg.Type {
+			case indexer.MessageStored:
+				// 跳過補發時已經發送過的事件
+				if last != nil && msg.Event.ID() <= last.ID() {
+					continue
+				}
+				last = msg.Event
+			case indexer.MessageReorg:
+				if last != nil && last.BlockNumber > msg.RolledBackTo {
+					last = nil
+				}
+			}
+			if err := sink.send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// sseSink 以 Server-Sent Events 格式輸出
+type sseSink struct {
+	w gin.ResponseWriter
+}
+
+func (s *sseSink) send(msg indexer.Message) error {
+	var err error
+	switch msg.Type {
+	case indexer.MessageStored:
+		data, _ := json.Marshal(msg.Event)
+		_, err = fmt.Fprintf(s.w, "id: %s\nevent: %s\ndata: %s\n\n", msg.Event.ID(), msg.Type, data)
+	case indexer.MessageReorg:
+		_, err = fmt.Fprintf(s.w, "event: %s\ndata: {\"rolledBackTo\":%d}\n\n", msg.Type, msg.RolledBackTo)
+	}
+	if err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+func (s *sseSink) ping() error {
+	if _, err := fmt.Fprint(s.w, ": ping\n\n"); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+// wsSink 以 WebSocket JSON 消息輸出
+type wsSink struct {
+	conn *websocket.Conn
+}
+
+func (s *wsSink) send(msg indexer.Message) error {
+	out := StreamMessage{Type: msg.Type}
+	switch msg.Type {
+	case indexer.MessageStored:
+		out.ID = msg.Event.ID()
+		out.Event = msg.Event
+	case indexer.MessageReorg:
+		rolledBackTo := msg.RolledBackTo
+		out.RolledBackTo = &rolledBackTo
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return s.conn.WriteJSON(out)
+}
+
+func (s *wsSink) ping() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+}
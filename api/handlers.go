@@ -1,72 +1,134 @@
 package api
 
 import (
+	"context"
 	"math/big"
 	"net/http"
+	"strconv"
+	"time"
 
 	"Abby/contracts"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/gin-gonic/gin"
 )
 
+// 預設 wait=true 時等待交易確認的最長時間
+const defaultWaitTimeout = 5 * time.Minute
+
 type StorageHandler struct {
-	interactor *contracts.ContractInteractor
+	interactor  *contracts.ContractInteractor
+	waitTimeout time.Duration
 }
 
 func NewStorageHandler(interactor *contracts.ContractInteractor) *StorageHandler {
 	return &StorageHandler{
-		interactor: interactor,
+		interactor:  interactor,
+		waitTimeout: defaultWaitTimeout,
 	}
 }
 
+// SetWaitTimeout 設置 wait=true 時等待交易確認的最長時間
+func (h *StorageHandler) SetWaitTimeout(timeout time.Duration) {
+	h.waitTimeout = timeout
+}
+
 // GetValue godoc
 // @Summary 獲取存儲的值
-// @Description 從智能合約中獲取當前存儲的值
+// @Description 從智能合約中獲取存儲的值，預設讀取最新區塊，block 可以指定區塊號、區塊哈希或標籤，返回實際讀取的區塊號和哈希
+// @Description 讀取較舊區塊需要歸檔節點，節點已裁剪該區塊的狀態時返回 422
 // @Tags storage
 // @Accept json
 // @Produce json
-// @Success 200 {object} object{value=string} "成功返回存儲的值"
-// @Failure 500 {object} object{error=string} "內部錯誤"
+// @Produce application/problem+json
+// @Param block query string false "讀取的區塊：區塊號、區塊哈希或 latest、pending、safe、finalized、earliest" default(latest)
+// @Success 200 {object} object{value=string,blockNumber=integer,blockHash=string} "成功返回存儲的值，pending 時沒有 blockHash"
+// @Failure 400 {object} Problem "block 參數格式錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 storage:read 權限"
+// @Failure 404 {object} Problem "區塊不存在、不在主鏈上 (block_not_found)，或合約在該區塊時還沒有部署 (contract_not_found)"
+// @Failure 422 {object} Problem "節點沒有該區塊的狀態，需要歸檔節點 (state_unavailable)"
+// @Failure 429 {object} Problem "超過該路由的請求速率，Retry-After 為需要等待的秒數"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 503 {object} Problem "無法連接 RPC 節點 (rpc_unavailable)"
+// @Failure 504 {object} Problem "RPC 節點沒有在超時前返回 (timeout)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /storage/value [get]
 func (h *StorageHandler) GetValue(c *gin.Context) {
-	value, err := h.interactor.GetValue()
+	ref, err := contracts.ParseBlockRef(c.DefaultQuery("block", "latest"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		abortWithProblem(c, newProblem(CodeInvalidRequest, err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"value": value.String(),
-	})
+	result, err := h.interactor.GetValueAt(c.Request.Context(), ref)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	response := gin.H{
+		"value":       result.Value.String(),
+		"blockNumber": result.BlockNumber,
+	}
+	if result.BlockHash != (common.Hash{}) {
+		response["blockHash"] = result.BlockHash.Hex()
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // SetValueRequest 設置值的請求結構
 type SetValueRequest struct {
 	Value string `json:"value" example:"42" binding:"required"`
+	// DryRun 只預執行並返回結果和 gas 估算，不發送交易
+	DryRun bool `json:"dryRun" example:"false"`
 }
 
 // SetValue godoc
 // @Summary 設置新的值
-// @Description 在智能合約中設置新的值
+// @Description 在智能合約中設置新的值。發送前先以發送帳戶在 pending 區塊上預執行，會回滾的交易不發送並返回 422 與解碼後的原因
+// @Description 預設發送交易後立即返回 202 與交易哈希，可透過 /tx/{hash} 查詢狀態；加上 wait=true 則等待交易被確認後才返回
+// @Description dryRun=true 時只返回預執行結果和 gas 估算，不發送交易
 // @Tags storage
 // @Accept json
 // @Produce json
+// @Produce application/problem+json
 // @Param request body SetValueRequest true "要設置的新值"
-// @Success 200 {object} object{message=string} "成功設置值"
-// @Failure 400 {object} object{error=string} "請求格式錯誤"
-// @Failure 500 {object} object{error=string} "內部錯誤"
+// @Param wait query bool false "是否等待交易被確認"
+// @Param Idempotency-Key header string false "重試時帶上相同的值，已發送交易的請求不會再次發送，而是返回之前的結果"
+// @Success 200 {object} object{message=string,txHash=string,nonce=integer,blockNumber=integer} "交易已確認；dryRun=true 時為 contracts.Simulation"
+// @Success 202 {object} object{message=string,txHash=string,nonce=integer,status=string} "交易已發送"
+// @Header 200,202 {string} X-Gas-Budget-Remaining "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+// @Header 200,202 {string} X-Gas-Budget-Reset "預算重置的時間 (下一個 UTC 零點)"
+// @Header 200,202 {string} Idempotent-Replayed "響應是同一個 Idempotency-Key 之前的結果時為 true"
+// @Failure 400 {object} Problem "請求格式錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 402 {object} Problem "發送帳戶的餘額不足以支付 gas (insufficient_funds)"
+// @Failure 403 {object} Problem "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)"
+// @Failure 409 {object} Problem "nonce 已被使用 (nonce_too_low)、同一 nonce 上已有費用更高的交易 (replacement_underpriced)，或同一個 Idempotency-Key 的請求仍在處理中 (idempotency_in_progress)"
+// @Failure 422 {object} Problem "交易預執行時回滾，沒有發送；wait=true 時交易在鏈上回滾，txHash 為該交易 (execution_reverted)；或 Idempotency-Key 已用於不同的請求體或 wait 參數 (idempotency_key_reused)"
+// @Failure 413 {object} Problem "帶 Idempotency-Key 的請求體超過 1 MiB (request_too_large)"
+// @Failure 429 {object} Problem "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 502 {object} Problem "無法連接外部簽名者 (signer_unavailable)"
+// @Failure 503 {object} Problem "無法連接 RPC 節點 (rpc_unavailable)、節點所在的鏈不符 (wrong_chain)，或所有發送帳戶都暫停使用 (no_sender)"
+// @Failure 504 {object} Problem "wait=true 時交易沒有在超時前確認，txHash 為已發送的交易；RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /storage/value [post]
 func (h *StorageHandler) SetValue(c *gin.Context) {
-	var request struct {
-		Value string `json:"value" binding:"required"`
-	}
+	var request SetValueRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid request body"))
+		return
+	}
+
+	wait, err := strconv.ParseBool(c.DefaultQuery("wait", "false"))
+	if err != nil {
+		abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid wait parameter"))
 		return
 	}
 
@@ -74,21 +136,54 @@ func (h *StorageHandler) SetValue(c *gin.Context) {
 	value := new(big.Int)
 	value, ok := value.SetString(request.Value, 10)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid number format",
+		abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid number format"))
+		return
+	}
+
+	if request.DryRun {
+		sim, err := h.interactor.SimulateSetValue(c.Request.Context(), value)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, sim)
+		return
+	}
+
+	tx, ok := sendWithBudget(c, func(ctx context.Context) (*types.Transaction, error) {
+		return h.interactor.SendSetValue(ctx, value)
+	})
+	if !ok {
+		return
+	}
+	rememberTx(c, tx)
+
+	if !wait {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Transaction submitted",
+			"txHash":  tx.Hash().Hex(),
+			"nonce":   tx.Nonce(),
+			"status":  contracts.TxStatusPending,
 		})
 		return
 	}
 
-	err := h.interactor.SetValue(value)
+	// 阻塞模式：等待交易被確認
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.waitTimeout)
+	defer cancel()
+	receipt, err := h.interactor.WaitMined(ctx, tx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		// 交易已發送，附上交易哈希讓客戶端繼續查詢狀態
+		problem := problemFromError(err)
+		problem.TxHash = tx.Hash().Hex()
+		abortWithProblem(c, problem)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Value updated successfully",
+		"message":     "Value updated successfully",
+		"txHash":      tx.Hash().Hex(),
+		"nonce":       tx.Nonce(),
+		"blockNumber": receipt.BlockNumber.Uint64(),
 	})
 }
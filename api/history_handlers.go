@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Abby/indexer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 每頁最多返回的事件數
+const maxHistoryLimit = 500
+
+type HistoryHandler struct {
+	indexer *indexer.Indexer
+}
+
+// NewHistoryHandler 創建歷史查詢 handler，indexer 為 nil 時表示未啟用事件索引
+func NewHistoryHandler(eventIndexer *indexer.Indexer) *HistoryHandler {
+	return &HistoryHandler{
+		indexer: eventIndexer,
+	}
+}
+
+// HistoryResponse 歷史查詢的返回結構
+type HistoryResponse struct {
+	Events []indexer.Event `json:"events"`
+	// NextCursor 下一頁的游標，為空表示沒有更多結果
+	NextCursor string `json:"nextCursor,omitempty" example:"00000000004fdf8700000000"`
+	// Indexing 索引進度，索引尚未追上最新區塊時結果可能不完整
+	Indexing indexer.Progress `json:"indexing"`
+	// Complete 查詢的區間是否已全部索引
+	Complete bool `json:"complete"`
+}
+
+// GetHistory godoc
+// @Summary 獲取歷史值
+// @Description 從已索引的 DataStored 事件中分頁查詢歷史值。索引尚未追上最新區塊時 complete 為 false，indexing 返回當前進度
+// @Tags storage
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param cursor query string false "上一頁返回的 nextCursor"
+// @Param limit query int false "每頁數量 (預設 50，最多 500)"
+// @Param order query string false "排序" Enums(asc, desc) default(desc)
+// @Param fromBlock query int false "起始區塊 (包含)"
+// @Param toBlock query int false "結束區塊 (包含)"
+// @Param fromTime query string false "起始時間 (包含)，Unix 秒數或 RFC3339"
+// @Param toTime query string false "結束時間 (包含)，Unix 秒數或 RFC3339"
+// @Param minValue query string false "最小值 (包含)"
+// @Param maxValue query string false "最大值 (包含)"
+// @Success 200 {object} HistoryResponse "歷史值"
+// @Failure 400 {object} Problem "查詢參數錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 storage:read 權限"
+// @Failure 429 {object} Problem "超過該路由的請求速率，Retry-After 為需要等待的秒數"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 503 {object} Problem "未啟用事件索引 (indexing_disabled)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /storage/history [get]
+func (h *HistoryHandler) GetHistory(c *gin.Context) {
+	if h.indexer == nil {
+		abortWithProblem(c, newProblem(CodeIndexingDisabled, "Event indexing is disabled"))
+		return
+	}
+
+	query, err := parseHistoryQuery(c)
+	if err != nil {
+		abortWithProblem(c, newProblem(CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	// 先讀進度再查詢，保證返回的進度不會比結果更新
+	progress, err := h.indexer.Progress()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	page, err := h.indexer.Store().Events(*query)
+	if errors.Is(err, indexer.ErrInvalidCursor) {
+		abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid cursor"))
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, HistoryResponse{
+		Events:     page.Events,
+		NextCursor: page.NextCursor,
+		Indexing:   *progress,
+		Complete:   progress.CaughtUp || (query.ToBlock != nil && *query.ToBlock <= progress.IndexedBlock),
+	})
+}
+
+// parseHistoryQuery 解析歷史查詢參數
+func parseHistoryQuery(c *gin.Context) (*indexer.Query, error) {
+	query := &indexer.Query{
+		Cursor:     c.Query("cursor"),
+		Limit:      indexer.DefaultLimit,
+		Descending: true,
+	}
+
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		query.Descending = false
+	case "desc":
+	default:
+		return nil, errors.New("Invalid order, must be asc or desc")
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxHistoryLimit {
+			return nil, errors.New("Invalid limit, must be between 1 and 500")
+		}
+		query.Limit = limit
+	}
+
+	var err error
+	if query.FromBlock, err = parseBlockQuery(c, "fromBlock"); err != nil {
+		return nil, err
+	}
+	if query.ToBlock, err = parseBlockQuery(c, "toBlock"); err != nil {
+		return nil, err
+	}
+	if query.FromTime, err = parseTimeQuery(c, "fromTime"); err != nil {
+		return nil, err
+	}
+	if query.ToTime, err = parseTimeQuery(c, "toTime"); err != nil {
+		return nil, err
+	}
+	if query.MinValue, err = parseValueQuery(c, "minValue"); err != nil {
+		return nil, err
+	}
+	if query.MaxValue, err = parseValueQuery(c, "maxValue"); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+func parseBlockQuery(c *gin.Context, name string) (*uint64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	block, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid " + name)
+	}
+	return &block, nil
+}
+
+// parseTimeQuery 解析 Unix 秒數或 RFC3339 格式的時間
+func parseTimeQuery(c *gin.Context, name string) (*uint64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	if seconds, err := strconv.ParseUint(v, 10, 64); err == nil {
+		return &seconds, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil || t.Unix() < 0 {
+		return nil, errors.New("Invalid " + name)
+	}
+	seconds := uint64(t.Unix())
+	return &seconds, nil
+}
+
+func parseValueQuery(c *gin.Context, name string) (*big.Int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	value, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return nil, errors.New("Invalid " + name)
+	}
+	return value, nil
+}
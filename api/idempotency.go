@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"Abby/contracts"
+	"Abby/idempotency"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// idempotencyHeader 客戶端重試時帶上相同的值，避免重複發送交易
+	idempotencyHeader = "Idempotency-Key"
+	// replayedHeader 響應來自之前保存的結果時設置為 true
+	replayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength Idempotency-Key 的最大長度
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize 帶 Idempotency-Key 的請求體的最大長度，超過時返回 413
+	maxIdempotentBodySize = 1 << 20
+)
+
+// idempotencyKey gin context 中保存當前請求的 *idempotentRequest 的鍵
+const idempotencyKey = "idempotency.request"
+
+// Idempotency 按 Idempotency-Key 記錄發送交易的請求，相同的重試直接返回之前的結果
+// nil 表示不啟用，Idempotency-Key 會被忽略
+type Idempotency struct {
+	store *idempotency.Store
+}
+
+// NewIdempotency 創建 Idempotency-Key 中間件
+func NewIdempotency(store *idempotency.Store) *Idempotency {
+	return &Idempotency{store: store}
+}
+
+// Handle 返回處理 Idempotency-Key 的中間件，應放在認證之後，同一個鍵只在同一個客戶端內有效
+// 只有發送了交易的請求會被記住，沒有發送交易就失敗的請求可以用同一個鍵重試
+func (i *Idempotency) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(idempotencyHeader)
+		if i == nil || header == "" {
+			c.Next()
+			return
+		}
+		if len(header) > maxIdempotencyKeyLength {
+			abortWithProblem(c, newProblem(CodeInvalidRequest, "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		// 多讀一個字節以發現超長的請求體，截斷後計算的哈希會把不同的請求當作相同
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid request body"))
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			abortWithProblem(c, newProblem(CodeRequestTooLarge, "Request body must be at most 1 MiB"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := clientID(c) + " " + header
+		record, err := i.store.Begin(key, fingerprint(body, c.Request.URL.Query()))
+		switch {
+		case err != nil:
+			abortWithError(c, err)
+			return
+		case record != nil:
+			replay(c, record)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		request := &idempotentRequest{store: i.store, key: key}
+		c.Set(idempotencyKey, request)
+
+		// 之後的 handler panic 時也要釋放沒有發送交易的鍵，否則重試會一直得到 409 直到記錄過期
+		// recover 之後重新 panic，交給 gin 的 Recovery 返回 500
+		defer func() {
+			panicked := recover()
+			// 交易已發送時記錄保留，等待打包失敗的請求重試時返回交易哈希而不是再次發送
+			var err error
+			switch {
+			case !request.sent:
+				err = i.store.Release(key)
+			case panicked == nil && recorder.Status() < http.StatusMultipleChoices:
+				err = i.store.Finish(key, recorder.Status(), recorder.body.Bytes())
+			}
+			if err != nil {
+				log.Printf("Failed to save idempotency record: %v", err)
+			}
+			if panicked != nil {
+				panic(panicked)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// fingerprint 請求的哈希，用於發現同一個 Idempotency-Key 被用於不同的請求
+// JSON 請求體解碼後重新編碼，鍵的順序和空白不同的相同請求得到相同的哈希；查詢參數 (例如 wait) 排序後一起計算
+func fingerprint(body []byte, query url.Values) string {
+	canonical := body
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err == nil && !decoder.More() {
+		if encoded, err := json.Marshal(decoded); err == nil {
+			canonical = encoded
+		}
+	}
+	hash := sha256.New()
+	hash.Write(canonical)
+	hash.Write([]byte{0})
+	hash.Write([]byte(query.Encode()))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotentRequest 帶 Idempotency-Key 的請求
+type idempotentRequest struct {
+	store *idempotency.Store
+	key   string
+	sent  bool
+}
+
+// rememberTx 記錄帶 Idempotency-Key 的請求已經發送的交易，應在發送成功後立即調用
+// 請求沒有 Idempotency-Key 時不做任何事
+func rememberTx(c *gin.Context, tx *types.Transaction) {
+	v, ok := c.Get(idempotencyKey)
+	if !ok {
+		return
+	}
+	request := v.(*idempotentRequest)
+	request.sent = true
+	if err := request.store.Sent(request.key, tx.Hash(), tx.Nonce()); err != nil {
+		log.Printf("Failed to save idempotency record: %v", err)
+	}
+}
+
+// replay 返回之前保存的結果；請求沒有成功返回時返回已發送交易的哈希，可透過 /tx/{hash} 查詢狀態
+func replay(c *gin.Context, record *idempotency.Record) {
+	c.Header(replayedHeader, "true")
+	if record.State == idempotency.StateDone {
+		c.Data(record.Status, "application/json; charset=utf-8", record.Body)
+		c.Abort()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusAccepted, gin.H{
+		"message": "Transaction submitted",
+		"txHash":  record.TxHash,
+		"nonce":   record.Nonce,
+		"status":  contracts.TxStatusPending,
+	})
+}
+
+// responseRecorder 在寫出響應的同時保存響應體
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Abby/idempotency"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyServer 在 Handle 之後掛上模擬發送交易的 handler，sends 為發送的次數
+// /send 發送交易，/panic 沒有發送就 panic，/block 發送前等待 release 被關閉
+type idempotencyServer struct {
+	router  *gin.Engine
+	sends   atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newIdempotencyServer(t *testing.T) *idempotencyServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	store, err := idempotency.OpenStore(filepath.Join(t.TempDir(), "idempotency.db"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	s := &idempotencyServer{router: gin.New(), entered: make(chan struct{}, 1), release: make(chan struct{})}
+	send := func(c *gin.Context) {
+		n := s.sends.Add(1)
+		tx := types.NewTx(&types.LegacyTx{Nonce: uint64(n), Gas: 21000, To: &common.Address{}})
+		rememberTx(c, tx)
+		c.JSON(http.StatusAccepted, gin.H{"txHash": tx.Hash().Hex()})
+	}
+	s.router.Use(gin.RecoveryWithWriter(io.Discard), NewIdempotency(store).Handle())
+	s.router.POST("/send", send)
+	s.router.POST("/panic", func(c *gin.Context) { panic("handler bug") })
+	s.router.POST("/block", func(c *gin.Context) {
+		s.entered <- struct{}{}
+		<-s.release
+		send(c)
+	})
+	return s
+}
+
+func (s *idempotencyServer) do(target, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyHandle(t *testing.T) {
+	type request struct{ target, body string }
+	send := request{"/send", `{"value":"1"}`}
+	tests := []struct {
+		name          string
+		first, second request
+		wantStatus    int
+		wantCode      string
+		wantReplay    bool
+		wantSends     int32
+	}{
+		{name: "same request", first: send, second: send, wantStatus: http.StatusAccepted, wantReplay: true, wantSends: 1},
+		{name: "same JSON with other formatting", first: send, second: request{"/send", "{ \"value\" : \"1\" }\n"}, wantStatus: http.StatusAccepted, wantReplay: true, wantSends: 1},
+		{name: "different body", first: send, second: request{"/send", `{"value":"2"}`}, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeIdempotencyKeyReused, wantSends: 1},
+		{name: "different query", first: send, second: request{"/send?wait=false", `{"value":"1"}`}, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeIdempotencyKeyReused, wantSends: 1},
+		{name: "panic releases the key", first: request{"/panic", `{"value":"1"}`}, second: send, wantStatus: http.StatusAccepted, wantSends: 1},
+		{name: "oversize body", second: request{"/send", `{"value":"` + strings.Repeat("1", maxIdempotentBodySize) + `"}`}, wantStatus: http.StatusRequestEntityTooLarge, wantCode: CodeRequestTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newIdempotencyServer(t)
+			var first *httptest.ResponseRecorder
+			if tt.first.target != "" {
+				first = s.do(tt.first.target, "key-1", tt.first.body)
+			}
+
+			w := s.do(tt.second.target, "key-1", tt.second.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Fatalf("body = %s, want code %s", w.Body, tt.wantCode)
+			}
+			if replayed := w.Header().Get(replayedHeader) == "true"; replayed != tt.wantReplay {
+				t.Fatalf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if tt.wantReplay && w.Body.String() != first.Body.String() {
+				t.Fatalf("replayed body = %s, want %s", w.Body, first.Body)
+			}
+			if n := s.sends.Load(); n != tt.wantSends {
+				t.Fatalf("sent %d transactions, want %d", n, tt.wantSends)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	s := newIdempotencyServer(t)
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.do("/block", "key-1", `{"value":"1"}`) }()
+	<-s.entered
+
+	// 第一個請求還沒有返回時，相同的請求返回 409 且不發送交易
+	w := s.do("/block", "key-1", `{"value":"1"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"code":"`+CodeIdempotencyInProgress+`"`) {
+		t.Fatalf("concurrent request: %d %s, want 409 %s", w.Code, w.Body, CodeIdempotencyInProgress)
+	}
+	// 其他鍵不受影響
+	if w := s.do("/send", "key-2", `{"value":"1"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("other key: %d %s", w.Code, w.Body)
+	}
+
+	close(s.release)
+	if first := <-done; first.Code != http.StatusAccepted {
+		t.Fatalf("first request: %d %s", first.Code, first.Body)
+	}
+	if w := s.do("/block", "key-1", `{"value":"1"}`); w.Code != http.StatusAccepted || w.Header().Get(replayedHeader) != "true" {
+		t.Fatalf("retry after completion: %d, replayed %q", w.Code, w.Header().Get(replayedHeader))
+	}
+	if n := s.sends.Load(); n != 2 {
+		t.Fatalf("sent %d transactions, want 2", n)
+	}
+}
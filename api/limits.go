@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"Abby/auth"
+	"Abby/contracts"
+	"Abby/quota"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+// limiterKey gin context 中保存限流器的鍵，發送交易的 handler 用它預留 gas 預算
+const limiterKey = "quota.limiter"
+
+// Limiter 按客戶端限流並檢查每日 gas 預算
+// nil 表示不限制，budget 為 nil 時只限流
+type Limiter struct {
+	rates  *quota.RateLimiter
+	budget *quota.Budget
+}
+
+// NewLimiter 創建限流器，rates 或 budget 為 nil 時不做對應的檢查
+func NewLimiter(rates *quota.RateLimiter, budget *quota.Budget) *Limiter {
+	return &Limiter{rates: rates, budget: budget}
+}
+
+// RateLimit 返回按客戶端和路由取令牌的中間件，應放在認證之後
+// 令牌不足時返回 429 和 Retry-After
+func (l *Limiter) RateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil || l.rates == nil {
+			c.Next()
+			return
+		}
+
+		route := c.Request.Method + " " + strings.TrimPrefix(c.FullPath(), basePath)
+		decision, limited := l.rates.Allow(clientID(c), route)
+		if !limited {
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		if !decision.Allowed {
+			c.Header("Retry-After", retryAfter(decision.RetryAfter))
+			abortWithProblem(c, newProblem(CodeRateLimited, "rate limit exceeded for "+route+", retry in "+retryAfter(decision.RetryAfter)+"s"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// GasBudget 返回檢查每日 gas 預算的中間件，用於會發送交易的路由
+// 預算已用完時返回 429，Retry-After 為距離 UTC 零點的秒數
+func (l *Limiter) GasBudget() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil || l.budget == nil {
+			c.Next()
+			return
+		}
+
+		usage, err := l.budget.Check(clientID(c))
+		if errors.Is(err, quota.ErrBudgetExceeded) {
+			abortBudgetExceeded(c, usage)
+			return
+		}
+		setBudgetHeaders(c, usage)
+		c.Set(limiterKey, l)
+		c.Next()
+	}
+}
+
+// sendWithBudget 調用 send 發送交易，廣播之前按交易的最高費用預留調用者的 gas 預算
+// 預算不足時返回 429 且不發送，發送失敗時撤銷預留；返回 false 表示已寫入錯誤響應
+// 路由沒有 gas 預算時直接發送
+func sendWithBudget(c *gin.Context, send func(ctx context.Context) (*types.Transaction, error)) (*types.Transaction, bool) {
+	v, ok := c.Get(limiterKey)
+	if !ok {
+		tx, err := send(c.Request.Context())
+		if err != nil {
+			abortWithError(c, err)
+			return nil, false
+		}
+		return tx, true
+	}
+
+	l := v.(*Limiter)
+	client := clientID(c)
+	var (
+		usage    quota.Usage
+		reserved *common.Hash
+	)
+	ctx := contracts.WithBeforeSend(c.Request.Context(), func(tx *types.Transaction) error {
+		var err error
+		usage, err = l.budget.TryReserve(client, tx.Hash(), quota.MaxCost(tx))
+		if err == nil {
+			hash := tx.Hash()
+			reserved = &hash
+		}
+		return err
+	})
+
+	tx, err := send(ctx)
+	if err != nil {
+		if reserved != nil {
+			l.budget.Release(*reserved)
+		}
+		if errors.Is(err, quota.ErrBudgetExceeded) {
+			abortBudgetExceeded(c, usage)
+			return nil, false
+		}
+		abortWithError(c, err)
+		return nil, false
+	}
+	setBudgetHeaders(c, usage)
+	return tx, true
+}
+
+// abortBudgetExceeded 返回 429 和預算使用情況，Retry-After 為距離 UTC 零點的秒數
+func abortBudgetExceeded(c *gin.Context, usage quota.Usage) {
+	setBudgetHeaders(c, usage)
+	c.Header("Retry-After", retryAfter(time.Until(usage.Reset)))
+	problem := problemFromError(quota.ErrBudgetExceeded)
+	problem.Budget = &BudgetUsage{
+		Limit:     usage.Limit.String(),
+		Spent:     usage.Spent.String(),
+		Reserved:  usage.Reserved.String(),
+		Remaining: usage.Remaining.String(),
+		Reset:     usage.Reset.Format(time.RFC3339),
+	}
+	abortWithProblem(c, problem)
+}
+
+func setBudgetHeaders(c *gin.Context, usage quota.Usage) {
+	c.Header("X-Gas-Budget-Limit", usage.Limit.String())
+	c.Header("X-Gas-Budget-Remaining", usage.Remaining.String())
+	c.Header("X-Gas-Budget-Reset", usage.Reset.Format(time.RFC3339))
+}
+
+// clientID 返回限流和預算使用的客戶端標識：API 密鑰 ID、JWT subject，匿名請求使用 IP
+func clientID(c *gin.Context) string {
+	principal, ok := PrincipalFrom(c)
+	if !ok || principal.Kind == auth.KindAnonymous {
+		return "ip:" + c.ClientIP()
+	}
+	return principal.Kind + ":" + principal.ID
+}
+
+// retryAfter 把等待時間轉換為 Retry-After 的秒數，至少 1 秒
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"Abby/auth"
+	"Abby/contracts"
+	"Abby/idempotency"
+	"Abby/indexer"
+	"Abby/quota"
+	"Abby/signer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType 錯誤響應的 Content-Type (RFC 9457)
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix 錯誤類型 URI 的前綴，後接錯誤碼
+const problemTypePrefix = "urn:abby:error:"
+
+// 錯誤碼，與 contracts 中節點相關的錯誤碼一起構成 API 的全部錯誤類型
+const (
+	CodeInvalidRequest        = "invalid_request"
+	CodeRequestTooLarge       = "request_too_large"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeRateLimited           = "rate_limited"
+	CodeGasBudgetExceeded     = "gas_budget_exceeded"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeReadOnly              = "read_only"
+	CodeSignerRejected        = "signer_rejected"
+	CodeSignerTimeout         = "signer_timeout"
+	CodeSignerUnavailable     = "signer_unavailable"
+	CodeNoSender              = "no_sender"
+	CodeTxNotFound            = "tx_not_found"
+	CodeTxNotPending          = "tx_not_pending"
+	CodeTxNotOwned            = "tx_not_owned"
+	CodeBlockNotFound         = "block_not_found"
+	CodeContractNotFound      = "contract_not_found"
+	CodeStateUnavailable      = "state_unavailable"
+	CodeIndexingDisabled      = "indexing_disabled"
+	CodeInternal              = "internal_error"
+)
+
+// problemTypes 每個錯誤碼的 HTTP 狀態碼和標題
+var problemTypes = map[string]struct {
+	status int
+	title  string
+}{
+	contracts.CodeInsufficientFunds:      {http.StatusPaymentRequired, "Insufficient funds"},
+	contracts.CodeNonceTooLow:            {http.StatusConflict, "Nonce too low"},
+	contracts.CodeReplacementUnderpriced: {http.StatusConflict, "Replacement transaction underpriced"},
+	contracts.CodeExecutionReverted:      {http.StatusUnprocessableEntity, "Execution reverted"},
+	contracts.CodeRPCUnavailable:         {http.StatusServiceUnavailable, "RPC endpoint unavailable"},
+	contracts.CodeTimeout:                {http.StatusGatewayTimeout, "Timed out"},
+	contracts.CodeWrongChain:             {http.StatusServiceUnavailable, "Wrong chain"},
+	CodeInvalidRequest:                   {http.StatusBadRequest, "Invalid request"},
+	CodeRequestTooLarge:                  {http.StatusRequestEntityTooLarge, "Request too large"},
+	CodeUnauthorized:                     {http.StatusUnauthorized, "Unauthorized"},
+	CodeForbidden:                        {http.StatusForbidden, "Forbidden"},
+	CodeRateLimited:                      {http.StatusTooManyRequests, "Rate limit exceeded"},
+	CodeGasBudgetExceeded:                {http.StatusTooManyRequests, "Daily gas budget exceeded"},
+	CodeIdempotencyKeyReused:             {http.StatusUnprocessableEntity, "Idempotency key reused"},
+	CodeIdempotencyInProgress:            {http.StatusConflict, "Request in progress"},
+	CodeReadOnly:                         {http.StatusForbidden, "Read-only mode"},
+	CodeSignerRejected:                   {http.StatusForbidden, "Signing rejected"},
+	CodeSignerTimeout:                    {http.StatusGatewayTimeout, "Signer timed out"},
+	CodeSignerUnavailable:                {http.StatusBadGateway, "Signer unavailable"},
+	CodeNoSender:                         {http.StatusServiceUnavailable, "No sender available"},
+	CodeTxNotFound:                       {http.StatusNotFound, "Transaction not found"},
+	CodeTxNotPending:                     {http.StatusConflict, "Transaction not pending"},
+	CodeTxNotOwned:                       {http.StatusConflict, "Transaction not owned"},
+	CodeBlockNotFound:                    {http.StatusNotFound, "Block not found"},
+	CodeContractNotFound:                 {http.StatusNotFound, "Contract not deployed"},
+	CodeStateUnavailable:                 {http.StatusUnprocessableEntity, "State unavailable"},
+	CodeIndexingDisabled:                 {http.StatusServiceUnavailable, "Event indexing disabled"},
+	CodeInternal:                         {http.StatusInternalServerError, "Internal error"},
+}
+
+// errorCodes 按順序匹配錯誤對應的錯誤碼
+// 外部簽名者的錯誤排在前面，它們可能同時包含節點錯誤
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{signer.ErrRejected, CodeSignerRejected},
+	{signer.ErrSignerTimeout, CodeSignerTimeout},
+	{signer.ErrSignerUnavailable, CodeSignerUnavailable},
+	{contracts.ErrReadOnly, CodeReadOnly},
+	{contracts.ErrNoSender, CodeNoSender},
+	{contracts.ErrTxNotFound, CodeTxNotFound},
+	{contracts.ErrTxNotPending, CodeTxNotPending},
+	{contracts.ErrTxNotOwned, CodeTxNotOwned},
+	{contracts.ErrBlockNotFound, CodeBlockNotFound},
+	{contracts.ErrNoCode, CodeContractNotFound},
+	{contracts.ErrStateUnavailable, CodeStateUnavailable},
+	{indexer.ErrInvalidCursor, CodeInvalidRequest},
+	{idempotency.ErrKeyReused, CodeIdempotencyKeyReused},
+	{idempotency.ErrInProgress, CodeIdempotencyInProgress},
+	{quota.ErrBudgetExceeded, CodeGasBudgetExceeded},
+	{auth.ErrInvalidKey, CodeUnauthorized},
+	{auth.ErrInvalidToken, CodeUnauthorized},
+}
+
+// Problem 錯誤響應 (RFC 9457 application/problem+json)
+type Problem struct {
+	// Type 錯誤類型的 URI，由錯誤碼決定
+	Type   string `json:"type" example:"urn:abby:error:insufficient_funds"`
+	Title  string `json:"title" example:"Insufficient funds"`
+	Status int    `json:"status" example:"402"`
+	// Detail 這次錯誤的說明，5xx 錯誤不返回詳細信息，避免洩露 RPC 地址和節點的原始錯誤
+	Detail string `json:"detail,omitempty" example:"failed to set value: insufficient funds for gas and value"`
+	// Instance 出錯的請求路徑
+	Instance string `json:"instance,omitempty" example:"/api/v1/storage/value"`
+	// Code 穩定的錯誤碼，客戶端應以它區分錯誤
+	Code string `json:"code" example:"insufficient_funds"`
+	// TxHash 交易已發送後才失敗時的交易哈希，可透過 /tx/{hash} 查詢狀態
+	TxHash string `json:"txHash,omitempty" example:"0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"`
+	// Revert 交易回滾時解碼後的原因
+	Revert *contracts.RevertError `json:"revert,omitempty"`
+	// Budget 超過每日 gas 預算時的用量
+	Budget *BudgetUsage `json:"budget,omitempty"`
+}
+
+// BudgetUsage 客戶端當天的 gas 預算用量 (wei)
+type BudgetUsage struct {
+	Limit     string `json:"limit" example:"10000000000000000"`
+	Spent     string `json:"spent" example:"9000000000000000"`
+	Reserved  string `json:"reserved" example:"1000000000000000"`
+	Remaining string `json:"remaining" example:"0"`
+	// Reset 預算重置的時間 (下一個 UTC 零點)
+	Reset string `json:"reset" example:"2024-01-02T00:00:00Z"`
+}
+
+// newProblem 創建錯誤碼對應的錯誤響應
+func newProblem(code, detail string) *Problem {
+	t, ok := problemTypes[code]
+	if !ok {
+		code, t = CodeInternal, problemTypes[CodeInternal]
+	}
+	return &Problem{
+		Type:   problemTypePrefix + code,
+		Title:  t.title,
+		Status: t.status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// problemFromError 把錯誤轉換為錯誤響應
+// 5xx 錯誤的信息可能包含 RPC 地址或節點的原始錯誤，只寫入日誌，響應中不返回
+func problemFromError(err error) *Problem {
+	code := errorCode(err)
+	problem := newProblem(code, err.Error())
+	if problem.Status >= http.StatusInternalServerError {
+		log.Printf("Request failed (%s): %v", code, err)
+		problem.Detail = ""
+		if code == CodeInternal {
+			problem.Detail = "see the server log for details"
+		}
+	}
+	var revert *contracts.RevertError
+	if errors.As(err, &revert) {
+		problem.Revert = revert
+	}
+	return problem
+}
+
+// errorCode 返回錯誤的錯誤碼，無法識別時返回 CodeInternal
+func errorCode(err error) string {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	if code := contracts.ErrorCode(err); code != "" {
+		return code
+	}
+	return CodeInternal
+}
+
+// abortWithProblem 寫入錯誤響應並停止執行之後的 handler
+func abortWithProblem(c *gin.Context, problem *Problem) {
+	problem.Instance = c.Request.URL.Path
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// abortWithError 把錯誤轉換為錯誤響應寫入並停止執行之後的 handler
+func abortWithError(c *gin.Context, err error) {
+	abortWithProblem(c, problemFromError(err))
+}
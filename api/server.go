@@ -1,29 +1,62 @@
 package api
 
 import (
-	_ "Abby/docs" // 這裡會引入自動生成的 swagger 文檔
+	"Abby/auth"
+	"Abby/docs" // 這裡會引入自動生成的 swagger 文檔
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// basePath API v1 的路徑前綴，限流配置中的路由不包含它
+const basePath = "/api/v1"
+
 // @title Simple Storage API
 // @version 1.0
 // @description 這是一個簡單的智能合約 API 服務
+// @description 錯誤響應使用 RFC 9457 的 application/problem+json 格式，code 欄位為穩定的錯誤碼，客戶端應以它而不是 detail 區分錯誤
 // @host localhost:8081
 // @BasePath /api/v1
 // @schemes http
-func SetupRouter(handler *StorageHandler) *gin.Engine {
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description abby apikey new 生成的 API 密鑰
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer " 加上 API 密鑰或 HS256 簽名的 JWT
+func SetupRouter(authenticator *Authenticator, limiter *Limiter, idempotent *Idempotency, handler *StorageHandler, txHandler *TxHandler, historyHandler *HistoryHandler, eventsHandler *EventsHandler) *gin.Engine {
 	r := gin.Default()
 
-	// API v1
-	v1 := r.Group("/api/v1")
+	// 在 swagger 文檔中標明服務當前是否只讀
+	docs.SwaggerInfo.Description = modeDescription(docs.SwaggerInfo.Description, handler.interactor.ReadOnly())
+
+	// API v1，按需要的權限分組，認證之後按客戶端限流
+	v1 := r.Group(basePath)
 	{
-		storage := v1.Group("/storage")
+		read := v1.Group("", authenticator.Require(auth.ScopeStorageRead), limiter.RateLimit())
+		{
+			read.GET("/storage/value", handler.GetValue)
+			read.GET("/storage/history", historyHandler.GetHistory)
+			read.GET("/storage/events", eventsHandler.Stream)
+			read.GET("/storage/events/ws", eventsHandler.StreamWebSocket)
+			read.GET("/tx/:hash", txHandler.GetStatus)
+		}
+
+		// 寫入會花費 gas，同時檢查每日預算
+		// 重試帶 Idempotency-Key 的請求時直接返回之前的結果，不受預算限制
+		write := v1.Group("", authenticator.Require(auth.ScopeStorageWrite), limiter.RateLimit())
 		{
-			storage.GET("/value", handler.GetValue)
-			storage.POST("/value", handler.SetValue)
+			write.POST("/storage/value", idempotent.Handle(), limiter.GasBudget(), handler.SetValue)
+			write.POST("/tx/:hash/speedup", limiter.GasBudget(), txHandler.SpeedUp)
+			write.POST("/tx/:hash/cancel", limiter.GasBudget(), txHandler.Cancel)
+		}
+
+		admin := v1.Group("", authenticator.Require(auth.ScopeAdmin), limiter.RateLimit())
+		{
+			admin.GET("/senders", txHandler.GetSenders)
 		}
 	}
 
@@ -32,3 +65,11 @@ func SetupRouter(handler *StorageHandler) *gin.Engine {
 
 	return r
 }
+
+// modeDescription 在文檔說明後加上服務當前的模式
+func modeDescription(description string, readOnly bool) string {
+	if readOnly {
+		return description + "\n\n**當前模式：只讀**。服務沒有配置簽名者，寫入接口 (POST /storage/value、/tx/{hash}/speedup、/tx/{hash}/cancel) 返回 403。"
+	}
+	return description + "\n\n**當前模式：讀寫**。"
+}
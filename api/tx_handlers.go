@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/gin-gonic/gin"
+)
+
+type TxHandler struct {
+	interactor *contracts.ContractInteractor
+}
+
+func NewTxHandler(interactor *contracts.ContractInteractor) *TxHandler {
+	return &TxHandler{
+		interactor: interactor,
+	}
+}
+
+// GetStatus godoc
+// @Summary 查詢交易狀態
+// @Description 查詢交易是 pending、mined、failed 還是 dropped，以及所在區塊、gas 用量和確認數
+// @Tags tx
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param hash path string true "交易哈希"
+// @Success 200 {object} contracts.TxStatus "交易狀態"
+// @Failure 400 {object} Problem "交易哈希格式錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 storage:read 權限"
+// @Failure 404 {object} Problem "找不到交易 (tx_not_found)"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 503 {object} Problem "無法連接 RPC 節點 (rpc_unavailable)"
+// @Failure 504 {object} Problem "RPC 節點沒有在超時前返回 (timeout)"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /tx/{hash} [get]
+func (h *TxHandler) GetStatus(c *gin.Context) {
+	hash, ok := parseTxHash(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.interactor.TxStatus(c.Request.Context(), hash)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SpeedUp godoc
+// @Summary 加速交易
+// @Description 以相同的 nonce 和 calldata、更高的費用重新發送仍在等待打包的交易
+// @Tags tx
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param hash path string true "交易哈希"
+// @Success 202 {object} object{message=string,txHash=string,replaces=string,nonce=integer} "替換交易已發送"
+// @Header 202 {string} X-Gas-Budget-Remaining "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+// @Header 202 {string} X-Gas-Budget-Reset "預算重置的時間 (下一個 UTC 零點)"
+// @Failure 400 {object} Problem "交易哈希格式錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 402 {object} Problem "發送帳戶的餘額不足以支付替換交易的費用 (insufficient_funds)"
+// @Failure 403 {object} Problem "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)"
+// @Failure 404 {object} Problem "找不到交易 (tx_not_found)"
+// @Failure 409 {object} Problem "交易已被打包 (tx_not_pending)、不是由本服務發送 (tx_not_owned)，或節點要求更高的替換費用 (replacement_underpriced)"
+// @Failure 429 {object} Problem "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 502 {object} Problem "無法連接外部簽名者 (signer_unavailable)"
+// @Failure 503 {object} Problem "無法連接 RPC 節點 (rpc_unavailable) 或節點所在的鏈不符 (wrong_chain)"
+// @Failure 504 {object} Problem "RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /tx/{hash}/speedup [post]
+func (h *TxHandler) SpeedUp(c *gin.Context) {
+	h.replace(c, h.interactor.SpeedUp)
+}
+
+// Cancel godoc
+// @Summary 取消交易
+// @Description 在相同的 nonce 上發送一筆 0 ETH 的自我轉帳以取消仍在等待打包的交易
+// @Tags tx
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Param hash path string true "交易哈希"
+// @Success 202 {object} object{message=string,txHash=string,replaces=string,nonce=integer} "取消交易已發送"
+// @Header 202 {string} X-Gas-Budget-Remaining "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+// @Header 202 {string} X-Gas-Budget-Reset "預算重置的時間 (下一個 UTC 零點)"
+// @Failure 400 {object} Problem "交易哈希格式錯誤"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 402 {object} Problem "發送帳戶的餘額不足以支付替換交易的費用 (insufficient_funds)"
+// @Failure 403 {object} Problem "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)"
+// @Failure 404 {object} Problem "找不到交易 (tx_not_found)"
+// @Failure 409 {object} Problem "交易已被打包 (tx_not_pending)、不是由本服務發送 (tx_not_owned)，或節點要求更高的替換費用 (replacement_underpriced)"
+// @Failure 429 {object} Problem "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數"
+// @Failure 500 {object} Problem "內部錯誤"
+// @Failure 502 {object} Problem "無法連接外部簽名者 (signer_unavailable)"
+// @Failure 503 {object} Problem "無法連接 RPC 節點 (rpc_unavailable) 或節點所在的鏈不符 (wrong_chain)"
+// @Failure 504 {object} Problem "RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /tx/{hash}/cancel [post]
+func (h *TxHandler) Cancel(c *gin.Context) {
+	h.replace(c, h.interactor.Cancel)
+}
+
+// GetSenders godoc
+// @Summary 查詢發送帳戶
+// @Description 列出發送帳戶池中每個帳戶的餘額、等待打包的交易數、已發送和失敗的交易數，以及是否因餘額不足或交易卡住而暫停使用
+// @Tags tx
+// @Accept json
+// @Produce json
+// @Produce application/problem+json
+// @Success 200 {array} contracts.SenderStats "發送帳戶"
+// @Failure 401 {object} Problem "缺少或無效的 API 密鑰或 JWT"
+// @Failure 403 {object} Problem "憑據沒有 admin 權限"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /senders [get]
+func (h *TxHandler) GetSenders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.interactor.SenderStats())
+}
+
+// replace 處理加速和取消請求的共同流程
+func (h *TxHandler) replace(c *gin.Context, send func(context.Context, common.Hash) (*types.Transaction, error)) {
+	hash, ok := parseTxHash(c)
+	if !ok {
+		return
+	}
+
+	tx, ok := sendWithBudget(c, func(ctx context.Context) (*types.Transaction, error) {
+		return send(ctx, hash)
+	})
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "Replacement transaction submitted",
+		"txHash":   tx.Hash().Hex(),
+		"replaces": hash.Hex(),
+		"nonce":    tx.Nonce(),
+	})
+}
+
+// parseTxHash 解析路徑中的交易哈希，格式錯誤時直接返回 400
+func parseTxHash(c *gin.Context) (common.Hash, bool) {
+	raw, err := hexutil.Decode(c.Param("hash"))
+	if err != nil || len(raw) != common.HashLength {
+		abortWithProblem(c, newProblem(CodeInvalidRequest, "Invalid transaction hash"))
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(raw), true
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// MinJWTSecretLength HS256 簽名密鑰的最短長度 (字節)
+const MinJWTSecretLength = 32
+
+// ErrInvalidToken JWT 格式錯誤、簽名不符、已過期、簽發者不符或沒有 sub
+var ErrInvalidToken = errors.New("invalid bearer token")
+
+// Claims JWT 的 claims，scope 以空格分隔，與 OAuth 2.0 的 scope 相同
+type Claims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// Scopes 返回 scope 中的權限
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// JWTVerifier 驗證 HS256 簽名的 JWT
+type JWTVerifier struct {
+	secret []byte
+	// issuer 不為空時要求 iss 與之相同
+	issuer string
+}
+
+// NewJWTVerifier 創建 JWT 驗證器
+func NewJWTVerifier(secret []byte, issuer string) *JWTVerifier {
+	return &JWTVerifier{secret: secret, issuer: issuer}
+}
+
+// Verify 驗證簽名、過期時間和簽發者，返回 claims，沒有 exp 或 sub 的 token 不被接受
+// sub 用於區分調用方的配額和冪等鍵，為空時所有這樣的 token 會被當作同一個調用方
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return v.secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+	if v.issuer != "" && !claims.VerifyIssuer(v.issuer, true) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	return claims, nil
+}
+
+// IssueJWT 簽發 HS256 JWT，供其他服務或測試使用
+func IssueJWT(secret []byte, issuer, subject string, scopes []string, ttl time.Duration) (string, error) {
+	if subject == "" {
+		return "", errors.New("subject is required")
+	}
+	scopes, err := ParseScopes(scopes)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &Claims{
+		Scope: strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestJWTVerify(t *testing.T) {
+	verifier := NewJWTVerifier(testSecret, "abby")
+	sign := func(claims jwt.RegisteredClaims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{Scope: ScopeStorageRead, RegisteredClaims: claims}).SignedString(testSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	expires := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	claims, err := verifier.Verify(sign(jwt.RegisteredClaims{Issuer: "abby", Subject: "svc-a", ExpiresAt: expires}))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "svc-a" || len(claims.Scopes()) != 1 {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.RegisteredClaims
+	}{
+		{"no sub", jwt.RegisteredClaims{Issuer: "abby", ExpiresAt: expires}},
+		{"no exp", jwt.RegisteredClaims{Issuer: "abby", Subject: "svc-a"}},
+		{"expired", jwt.RegisteredClaims{Issuer: "abby", Subject: "svc-a", ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}},
+		{"other issuer", jwt.RegisteredClaims{Issuer: "other", Subject: "svc-a", ExpiresAt: expires}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(sign(tt.claims)); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestIssueJWTRequiresSubject(t *testing.T) {
+	if _, err := IssueJWT(testSecret, "abby", "", []string{ScopeStorageRead}, time.Hour); err == nil {
+		t.Fatal("IssueJWT without a subject succeeded")
+	}
+	token, err := IssueJWT(testSecret, "abby", "svc-a", []string{ScopeStorageRead}, time.Hour)
+	if err != nil {
+		t.Fatalf("IssueJWT: %v", err)
+	}
+	if _, err := NewJWTVerifier(testSecret, "abby").Verify(token); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
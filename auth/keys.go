@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKeysFile 預設的 API 密鑰文件
+const DefaultKeysFile = "apikeys.json"
+
+// KeyPrefix API 密鑰的前綴，完整格式為 abby_<id>_<secret>
+const KeyPrefix = "abby_"
+
+// reloadInterval 檢查密鑰文件是否被 abby apikey 修改的最短間隔
+const reloadInterval = time.Second
+
+var (
+	// ErrInvalidKey 密鑰格式錯誤、不存在或哈希不符
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrKeyRevoked 密鑰已被吊銷
+	ErrKeyRevoked = errors.New("API key has been revoked")
+	// ErrKeyNotFound 密鑰文件中沒有該 ID
+	ErrKeyNotFound = errors.New("API key not found")
+)
+
+// Key 一個 API 密鑰，文件中只保存密鑰的 SHA-256 哈希
+type Key struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"createdAt"`
+	RevokedAt time.Time `json:"revokedAt,omitzero"`
+}
+
+// Revoked 返回密鑰是否已被吊銷
+func (k Key) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// KeyStore 保存 API 密鑰哈希的 JSON 文件，文件被其他進程修改後自動重新讀取
+type KeyStore struct {
+	path string
+
+	mu      sync.Mutex
+	keys    []Key
+	modTime time.Time
+	checked time.Time
+}
+
+// OpenKeyStore 讀取密鑰文件，文件不存在時返回空的密鑰列表
+func OpenKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path 返回密鑰文件的路徑
+func (s *KeyStore) Path() string {
+	return s.path
+}
+
+// Create 生成新的密鑰並保存它的哈希，返回的完整密鑰只有這一次可以看到
+func (s *KeyStore) Create(name string, scopes []string) (string, Key, error) {
+	scopes, err := ParseScopes(scopes)
+	if err != nil {
+		return "", Key{}, err
+	}
+	if len(scopes) == 0 {
+		return "", Key{}, errors.New("at least one scope is required")
+	}
+
+	id, err := randomString(6, hex.EncodeToString)
+	if err != nil {
+		return "", Key{}, err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", Key{}, err
+	}
+	full := KeyPrefix + id + "_" + secret
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := Key{
+		ID:        id,
+		Name:      name,
+		Hash:      hashKey(full),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.keys = append(s.keys, key)
+	if err := s.save(); err != nil {
+		return "", Key{}, err
+	}
+	return full, key, nil
+}
+
+// List 返回所有密鑰，包括已吊銷的
+func (s *KeyStore) List() []Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Key(nil), s.keys...)
+}
+
+// Revoke 吊銷密鑰，記錄保留在文件中
+func (s *KeyStore) Revoke(id string) (Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID != id {
+			continue
+		}
+		if !s.keys[i].Revoked() {
+			s.keys[i].RevokedAt = time.Now().UTC()
+			if err := s.save(); err != nil {
+				return Key{}, err
+			}
+		}
+		return s.keys[i], nil
+	}
+	return Key{}, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+}
+
+// Verify 驗證完整密鑰，返回對應的記錄
+func (s *KeyStore) Verify(full string) (Key, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(full, KeyPrefix), "_")
+	if !strings.HasPrefix(full, KeyPrefix) || !ok {
+		return Key{}, ErrInvalidKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reload()
+	hash := hashKey(full)
+	for _, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash)) != 1 {
+			return Key{}, ErrInvalidKey
+		}
+		if key.Revoked() {
+			return Key{}, ErrKeyRevoked
+		}
+		return key, nil
+	}
+	return Key{}, ErrInvalidKey
+}
+
+// reload 文件修改時間變化時重新讀取，讀取失敗時保留原有的密鑰
+func (s *KeyStore) reload() {
+	if time.Since(s.checked) < reloadInterval {
+		return
+	}
+	s.checked = time.Now()
+	info, err := os.Stat(s.path)
+	if err != nil || info.ModTime().Equal(s.modTime) {
+		return
+	}
+	s.load()
+}
+
+func (s *KeyStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read API keys: %v", err)
+	}
+	var keys []Key
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse API keys %s: %v", s.path, err)
+	}
+	s.keys = keys
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// save 先寫入臨時文件再重命名，文件只有所有者可以讀寫
+func (s *KeyStore) save() error {
+	data, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write API keys: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write API keys: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write API keys: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write API keys: %v", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// hashKey 密鑰是 32 字節的隨機數，用 SHA-256 保存即可，不需要慢哈希
+func hashKey(full string) string {
+	sum := sha256.Sum256([]byte(full))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %v", err)
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// API 權限
+const (
+	// ScopeStorageRead 讀取存儲的值、歷史、事件和交易狀態
+	ScopeStorageRead = "storage:read"
+	// ScopeStorageWrite 發送、加速和取消交易
+	ScopeStorageWrite = "storage:write"
+	// ScopeAdmin 查看發送帳戶等管理接口，包含所有其他權限
+	ScopeAdmin = "admin"
+)
+
+// Scopes 所有支持的權限
+var Scopes = []string{ScopeStorageRead, ScopeStorageWrite, ScopeAdmin}
+
+// ParseScopes 檢查權限名稱並去掉重複項
+func ParseScopes(scopes []string) ([]string, error) {
+	var parsed []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(Scopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q, supported: %s", scope, strings.Join(Scopes, ", "))
+		}
+		if !slices.Contains(parsed, scope) {
+			parsed = append(parsed, scope)
+		}
+	}
+	return parsed, nil
+}
+
+// HasScope 返回 granted 是否包含 scope，admin 包含所有權限
+func HasScope(granted []string, scope string) bool {
+	return slices.Contains(granted, scope) || slices.Contains(granted, ScopeAdmin)
+}
+
+// 調用者的類型
+const (
+	KindAPIKey    = "apikey"
+	KindJWT       = "jwt"
+	KindAnonymous = "anonymous"
+)
+
+// Principal 通過認證的調用者
+type Principal struct {
+	// Kind 認證方式：apikey、jwt 或 anonymous
+	Kind string
+	// ID API 密鑰 ID 或 JWT 的 sub，匿名調用者為空
+	ID     string
+	Name   string
+	Scopes []string
+}
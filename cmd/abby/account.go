@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"Abby/config"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// accountResult account 命令每個帳戶的 JSON 輸出
+type accountResult struct {
+	Address string `json:"address"`
+	File    string `json:"file"`
+}
+
+func runAccount(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("account", "new | import [keyfile] | list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("account expects a subcommand: new, import or list")
+	}
+	// 參數也可以寫在子命令之後，例如 abby account list --keystore dir
+	sub := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+	rest := fs.Args()
+
+	cfg, err := app.loader.Load(config.Requirements{Offline: true})
+	if err != nil {
+		return err
+	}
+	ks := cfg.Keystore()
+
+	switch sub {
+	case "new":
+		if len(rest) != 0 {
+			return errors.New("account new takes no arguments")
+		}
+		passphrase, err := cfg.Passphrase().ReadNew("Passphrase for the new account: ")
+		if err != nil {
+			return err
+		}
+		account, err := ks.NewAccount(passphrase)
+		if err != nil {
+			return err
+		}
+		return app.printer().result(newAccountResult(account), accountRows(account))
+
+	case "import":
+		if len(rest) > 1 {
+			return errors.New("account import takes at most one key file")
+		}
+		// 沒有指定文件時導入 PRIVATE_KEY，方便從明文私鑰遷移到 keystore
+		var key *ecdsa.PrivateKey
+		switch {
+		case len(rest) == 1:
+			if key, err = crypto.LoadECDSA(rest[0]); err != nil {
+				return fmt.Errorf("failed to read key file: %v", err)
+			}
+		case cfg.Signer.PrivateKey != "":
+			if key, err = crypto.HexToECDSA(strings.TrimPrefix(cfg.Signer.PrivateKey, "0x")); err != nil {
+				return fmt.Errorf("failed to convert private key: %v", err)
+			}
+		default:
+			return errors.New("account import expects a key file or PRIVATE_KEY")
+		}
+		passphrase, err := cfg.Passphrase().ReadNew("Passphrase for the imported account: ")
+		if err != nil {
+			return err
+		}
+		account, err := ks.ImportKey(key, passphrase)
+		if err != nil {
+			return err
+		}
+		return app.printer().result(newAccountResult(account), accountRows(account))
+
+	case "list":
+		all := ks.Accounts()
+		if len(all) == 0 {
+			return fmt.Errorf("no accounts in keystore %s", cfg.Signer.Keystore)
+		}
+		out := app.printer()
+		header := fmt.Sprintf("%-5s  %-42s  %s", "#", "ADDRESS", "FILE")
+		for i, account := range all {
+			line := fmt.Sprintf("%-5d  %-42s  %s", i, account.Address.Hex(), account.URL.Path)
+			if err := out.stream(newAccountResult(account), header, line); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown account subcommand %q", sub)
+	}
+}
+
+func newAccountResult(account accounts.Account) accountResult {
+	return accountResult{
+		Address: account.Address.Hex(),
+		File:    account.URL.Path,
+	}
+}
+
+func accountRows(account accounts.Account) []row {
+	return []row{
+		{"Address", account.Address.Hex()},
+		{"File", account.URL.Path},
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Abby/auth"
+	"Abby/config"
+)
+
+// apiKeyResult apikey 命令每個密鑰的 JSON 輸出，Key 只在創建時返回
+type apiKeyResult struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	Key       string     `json:"key,omitempty"`
+}
+
+// tokenResult apikey token 命令的 JSON 輸出
+type tokenResult struct {
+	Token     string    `json:"token"`
+	Subject   string    `json:"subject"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func runAPIKey(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("apikey", "new | list | revoke <id> | token")
+	name := fs.String("name", "", "name of the new key, for example the client that uses it")
+	scopes := fs.String("scopes", auth.ScopeStorageRead, "comma separated scopes: "+strings.Join(auth.Scopes, ", "))
+	subject := fs.String("subject", "", "sub claim of the token")
+	ttl := fs.Duration("ttl", time.Hour, "lifetime of the token")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("apikey expects a subcommand: new, list, revoke or token")
+	}
+	// 參數也可以寫在子命令之後，例如 abby apikey new --scopes storage:write
+	sub := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return err
+	}
+	rest := fs.Args()
+
+	cfg, err := app.loader.Load(config.Requirements{Offline: true})
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "new":
+		if len(rest) != 0 {
+			return errors.New("apikey new takes no arguments")
+		}
+		keys, err := cfg.OpenKeyStore()
+		if err != nil {
+			return err
+		}
+		full, key, err := keys.Create(*name, splitScopes(*scopes))
+		if err != nil {
+			return err
+		}
+		result := newAPIKeyResult(key)
+		result.Key = full
+		return app.printer().result(result, append(apiKeyRows(key),
+			row{"Key", full},
+			row{"Note", "the key is shown only once, store it now"},
+		))
+
+	case "list":
+		keys, err := cfg.OpenKeyStore()
+		if err != nil {
+			return err
+		}
+		all := keys.List()
+		if len(all) == 0 {
+			return fmt.Errorf("no API keys in %s", keys.Path())
+		}
+		out := app.printer()
+		header := fmt.Sprintf("%-12s  %-20s  %-32s  %-20s  %s", "ID", "NAME", "SCOPES", "CREATED", "STATUS")
+		for _, key := range all {
+			status := "active"
+			if key.Revoked() {
+				status = "revoked " + key.RevokedAt.Format(time.DateTime)
+			}
+			line := fmt.Sprintf("%-12s  %-20s  %-32s  %-20s  %s", key.ID, key.Name, strings.Join(key.Scopes, ","), key.CreatedAt.Format(time.DateTime), status)
+			if err := out.stream(newAPIKeyResult(key), header, line); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "revoke":
+		if len(rest) != 1 {
+			return errors.New("apikey revoke expects the key id")
+		}
+		keys, err := cfg.OpenKeyStore()
+		if err != nil {
+			return err
+		}
+		key, err := keys.Revoke(rest[0])
+		if err != nil {
+			return err
+		}
+		return app.printer().result(newAPIKeyResult(key), apiKeyRows(key))
+
+	case "token":
+		if cfg.Auth.JWTSecret == "" {
+			return errors.New("apikey token needs AUTH_JWT_SECRET")
+		}
+		if *subject == "" {
+			return errors.New("apikey token needs --subject")
+		}
+		granted := splitScopes(*scopes)
+		token, err := auth.IssueJWT([]byte(cfg.Auth.JWTSecret), cfg.Auth.JWTIssuer, *subject, granted, *ttl)
+		if err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(*ttl).UTC().Truncate(time.Second)
+		return app.printer().result(tokenResult{
+			Token:     token,
+			Subject:   *subject,
+			Scopes:    granted,
+			ExpiresAt: expiresAt,
+		}, []row{
+			{"Subject", *subject},
+			{"Scopes", strings.Join(granted, ",")},
+			{"Expires", expiresAt.Format(time.DateTime)},
+			{"Token", token},
+		})
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown apikey subcommand %q", sub)
+	}
+}
+
+func splitScopes(v string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(v, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func newAPIKeyResult(key auth.Key) apiKeyResult {
+	result := apiKeyResult{
+		ID:        key.ID,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	}
+	if key.Revoked() {
+		revokedAt := key.RevokedAt
+		result.RevokedAt = &revokedAt
+	}
+	return result
+}
+
+func apiKeyRows(key auth.Key) []row {
+	rows := []row{
+		{"ID", key.ID},
+		{"Name", key.Name},
+		{"Scopes", strings.Join(key.Scopes, ",")},
+		{"Created", key.CreatedAt.Format(time.DateTime)},
+	}
+	if key.Revoked() {
+		rows = append(rows, row{"Revoked", key.RevokedAt.Format(time.DateTime)})
+	}
+	return rows
+}
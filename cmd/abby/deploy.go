@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Abby/config"
+	"Abby/contracts"
+	"Abby/registry"
+)
+
+// estimateResult estimate 命令的 JSON 輸出
+type estimateResult struct {
+	*contracts.DeploymentEstimate
+	Sufficient bool `json:"sufficient"`
+}
+
+// deployResult deploy 命令的 JSON 輸出
+type deployResult struct {
+	ChainID    uint64                        `json:"chainId"`
+	Deployment registry.Entry                `json:"deployment"`
+	Registry   string                        `json:"registry"`
+	Estimate   *contracts.DeploymentEstimate `json:"estimate"`
+}
+
+func runEstimate(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("estimate", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{Signer: true})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	from, err := cfg.DeployerAddress()
+	if err != nil {
+		return err
+	}
+	estimate, err := contracts.EstimateDeployment(ctx, client, from, cfg.DeployOptions())
+	if err != nil {
+		return fmt.Errorf("failed to estimate deployment: %v", err)
+	}
+
+	rows := estimateRows(estimate)
+	if !estimate.Sufficient() {
+		rows = append(rows, row{"Shortfall", fmt.Sprintf("%f ETH", contracts.WeiToEth(estimate.Shortfall))})
+	}
+	return app.printer().result(estimateResult{estimate, estimate.Sufficient()}, rows)
+}
+
+func runDeploy(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("deploy", "")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{Signer: true})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	txSigner, err := cfg.NewDeployer()
+	if err != nil {
+		return err
+	}
+
+	// 先打開部署記錄，避免部署之後才發現無法寫入
+	reg, err := cfg.OpenRegistry()
+	if err != nil {
+		return err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain id: %v", err)
+	}
+
+	// 等待部署交易被打包的時間不超過 timeouts.txWait
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeouts.TxWait))
+	defer cancel()
+	deployment, err := contracts.DeployContract(waitCtx, client, txSigner, cfg.DeployOptions())
+	if err != nil {
+		// 交易已經發出但沒有確認成功時不寫入部署記錄，輸出交易哈希和合約地址以便之後自行檢查
+		if deployment != nil {
+			return fmt.Errorf("failed to deploy contract (transaction %s, contract address %s, not recorded in %s): %v",
+				deployment.Tx.Hash().Hex(), deployment.Address.Hex(), reg.Path(), err)
+		}
+		return fmt.Errorf("failed to deploy contract: %v", err)
+	}
+
+	entry := registry.NewSimpleStorageEntry(deployment)
+	if err := reg.Put(chainID.Uint64(), registry.SimpleStorage, entry); err != nil {
+		return err
+	}
+
+	rows := append([]row{
+		{"Contract address", entry.Address.Hex()},
+		{"Transaction", entry.TxHash.Hex()},
+		{"Block", entry.BlockNumber},
+		{"Chain ID", chainID},
+		{"Registry", reg.Path()},
+	}, estimateRows(deployment.Estimate)...)
+	return app.printer().result(deployResult{
+		ChainID:    chainID.Uint64(),
+		Deployment: entry,
+		Registry:   reg.Path(),
+		Estimate:   deployment.Estimate,
+	}, rows)
+}
+
+// estimateRows 部署預覽的表格行
+func estimateRows(estimate *contracts.DeploymentEstimate) []row {
+	return []row{
+		{"From", estimate.From.Hex()},
+		{"Nonce", estimate.Nonce},
+		{"Gas estimate", estimate.GasEstimate},
+		{"Gas limit", estimate.GasLimit},
+		{"Fees", estimate.Fees},
+		{"Cost", fmt.Sprintf("%f - %f ETH", contracts.WeiToEth(estimate.MinCost), contracts.WeiToEth(estimate.MaxCost))},
+		{"Balance", fmt.Sprintf("%f ETH", contracts.WeiToEth(estimate.Balance))},
+	}
+}
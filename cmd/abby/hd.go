@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"Abby/config"
+	"Abby/contracts"
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+// hdResult hd 命令每個派生帳戶的 JSON 輸出
+type hdResult struct {
+	Index   uint32   `json:"index"`
+	Path    string   `json:"path"`
+	Address string   `json:"address"`
+	Balance *big.Int `json:"balance"`
+	// Role 帳戶在配置中的用途：deployer、sender 或兩者
+	Role string `json:"role,omitempty"`
+}
+
+// runHD 列出助記詞在基礎路徑下前 N 個索引派生的地址和餘額
+func runHD(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("hd", "")
+	count := fs.Uint("count", 5, "number of indices to derive, starting from 0")
+	base := fs.String("hd.base", signer.DefaultHDBasePath, "base derivation path, the index is appended as the last level")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	basePath, err := accounts.ParseDerivationPath(*base)
+	if err != nil {
+		return fmt.Errorf("invalid --hd.base: %v", err)
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	wallet, err := cfg.HDWallet()
+	if err != nil {
+		return err
+	}
+	roles := hdRoles(cfg)
+
+	out := app.printer()
+	header := fmt.Sprintf("%-5s  %-22s  %-42s  %-20s  %s", "INDEX", "PATH", "ADDRESS", "BALANCE (ETH)", "ROLE")
+	for i := uint32(0); i < uint32(*count); i++ {
+		path := signer.HDPath(basePath, i)
+		key, err := wallet.Derive(path)
+		if err != nil {
+			return err
+		}
+		s := signer.NewKeySigner(key)
+		balance, err := client.BalanceAt(ctx, s.Address(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to get balance of %s: %v", s.Address().Hex(), err)
+		}
+
+		result := hdResult{
+			Index:   i,
+			Path:    path.String(),
+			Address: s.Address().Hex(),
+			Balance: balance,
+			Role:    roles[path.String()],
+		}
+		line := fmt.Sprintf("%-5d  %-22s  %-42s  %-20f  %s", i, result.Path, result.Address, contracts.WeiToEth(balance), result.Role)
+		if err := out.stream(result, header, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hdRoles 按派生路徑返回配置的助記詞簽名者中部署帳戶和發送帳戶的用途
+func hdRoles(cfg *config.Config) map[string]string {
+	roles := make(map[string]string)
+	if cfg.Signer.Type != config.SignerMnemonic {
+		return roles
+	}
+	add := func(raw, role string) {
+		path, err := accounts.ParseDerivationPath(raw)
+		if err != nil {
+			return
+		}
+		if roles[path.String()] != "" {
+			role = roles[path.String()] + "," + role
+		}
+		roles[path.String()] = role
+	}
+	add(cfg.Signer.DeployerPath, "deployer")
+	senders := cfg.Signer.SenderPaths
+	if len(senders) == 0 {
+		senders = []string{cfg.Signer.DeployerPath}
+	}
+	for _, raw := range senders {
+		add(raw, "sender")
+	}
+	return roles
+}
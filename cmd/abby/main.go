@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"Abby/config"
+	"Abby/contracts"
+	"Abby/registry"
+)
+
+// command 一個子命令
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = []command{
+	{"estimate", "", "preview the deployment gas, cost and balance", runEstimate},
+	{"deploy", "", "deploy the SimpleStorage contract", runDeploy},
+	{"get", "", "read the stored value", runGet},
+	{"set", "<value>", "store a new value", runSet},
+	{"watch", "", "print DataStored events as they are emitted", runWatch},
+	{"serve", "", "run the HTTP API server", runServe},
+	{"account", "new | import [keyfile] | list", "manage encrypted keystore accounts", runAccount},
+	{"apikey", "new | list | revoke <id> | token", "manage API keys and issue JWTs for the HTTP API", runAPIKey},
+	{"hd", "", "list addresses and balances derived from the mnemonic", runHD},
+	{"signer", "", "run a local Clef-compatible signer for testing the remote signer", runSigner},
+}
+
+// app 所有子命令共用的狀態：配置加載器和輸出格式
+type app struct {
+	loader *config.Loader
+	output string
+}
+
+func main() {
+	a := &app{loader: config.NewLoader()}
+	global := flag.NewFlagSet("abby", flag.ExitOnError)
+	a.registerFlags(global)
+	global.Usage = func() { usage(global) }
+	global.Parse(os.Args[1:])
+
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	name := global.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := cmd.run(ctx, a, global.Args()[1:])
+		stop()
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	global.Usage()
+	os.Exit(2)
+}
+
+// registerFlags 註冊網絡、簽名等共用參數和 --output，全局和子命令都可以使用
+func (a *app) registerFlags(fs *flag.FlagSet) {
+	a.loader.RegisterFlags(fs)
+	fs.Func("output", "output format: table or json (default table)", func(v string) error {
+		if v != outputTable && v != outputJSON {
+			return fmt.Errorf("must be %s or %s", outputTable, outputJSON)
+		}
+		a.output = v
+		return nil
+	})
+}
+
+// flagSet 創建子命令的參數集合，包含所有共用參數
+func (a *app) flagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	a.registerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: abby %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// printer 返回按 --output 輸出結果的 printer
+func (a *app) printer() *printer {
+	return newPrinter(os.Stdout, a.output)
+}
+
+// connect 加載配置並連接到配置的網絡
+func (a *app) connect(ctx context.Context, req config.Requirements) (*config.Config, contracts.Backend, func(), error) {
+	cfg, err := a.loader.Load(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cfg.Dev.Enabled {
+		return nil, nil, nil, errors.New("dev mode is only supported by abby serve")
+	}
+	client, err := cfg.Dial(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return cfg, client, client.Close, nil
+}
+
+// resolveContract 按連接的鏈 ID 從配置或部署記錄中確定合約
+func (a *app) resolveContract(ctx context.Context, cfg *config.Config, client contracts.Backend) (registry.Entry, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return registry.Entry{}, fmt.Errorf("failed to get chain id: %v", err)
+	}
+	reg, err := cfg.OpenRegistry()
+	if err != nil {
+		return registry.Entry{}, err
+	}
+	return cfg.ResolveContract(reg, chainID.Uint64())
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "Usage: abby [global flags] <command> [flags] [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nGlobal flags, also accepted after the command:")
+	fs.PrintDefaults()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// 輸出格式
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// row 表格輸出中的一行：名稱和值
+type row struct {
+	key   string
+	value any
+}
+
+// printer 以表格或 JSON 輸出命令結果
+type printer struct {
+	w    io.Writer
+	json bool
+	// headerDone 串流輸出的表頭是否已經打印
+	headerDone bool
+}
+
+func newPrinter(w io.Writer, format string) *printer {
+	return &printer{w: w, json: format == outputJSON}
+}
+
+// result 輸出單個結果，JSON 格式輸出 v，表格格式輸出 rows
+func (p *printer) result(v any, rows []row) error {
+	if p.json {
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%v\n", r.key, r.value)
+	}
+	return tw.Flush()
+}
+
+// stream 輸出串流中的一條記錄，JSON 格式每行一個對象，表格格式輸出 line，第一條記錄前先輸出 header
+func (p *printer) stream(v any, header, line string) error {
+	if p.json {
+		return json.NewEncoder(p.w).Encode(v)
+	}
+
+	if !p.headerDone {
+		fmt.Fprintln(p.w, header)
+		p.headerDone = true
+	}
+	_, err := fmt.Fprintln(p.w, line)
+	return err
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"Abby/api"
+	"Abby/config"
+	"Abby/contracts"
+	"Abby/devchain"
+	"Abby/idempotency"
+	"Abby/indexer"
+	"Abby/quota"
+	"Abby/registry"
+	"Abby/signer"
+)
+
+// shutdownTimeout 關閉服務器時等待進行中請求的最長時間
+const shutdownTimeout = 10 * time.Second
+
+func runServe(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("serve", "")
+	app.loader.RegisterServerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 沒有配置簽名者時以只讀模式運行
+	cfg, err := app.loader.Load(config.Requirements{})
+	if err != nil {
+		return err
+	}
+
+	var (
+		client     contracts.Backend
+		deployment registry.Entry
+		txSigners  []signer.Signer
+	)
+
+	if cfg.Dev.Enabled {
+		// 啟動模擬鏈並自動部署合約
+		options := devchain.DefaultOptions()
+		options.BlockTime = time.Duration(cfg.Dev.BlockTime)
+		options.Accounts = cfg.Dev.Accounts
+		chain, err := devchain.New(options)
+		if err != nil {
+			return fmt.Errorf("failed to start dev chain: %v", err)
+		}
+		defer chain.Close()
+
+		address, err := chain.DeploySimpleStorage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to deploy contract on dev chain: %v", err)
+		}
+
+		fmt.Println("=== Dev mode ===")
+		for i, account := range chain.Accounts {
+			fmt.Printf("Account #%d: %s (private key %s)\n", i, account.Address.Hex(), account.PrivateKeyHex())
+		}
+
+		client = chain
+		deployment = registry.Entry{Address: address}
+		for _, account := range chain.Accounts[:cfg.Dev.Senders] {
+			txSigners = append(txSigners, signer.NewKeySigner(account.PrivateKey))
+		}
+	} else {
+		// 連接到配置的網絡
+		ethClient, err := cfg.Dial(ctx)
+		if err != nil {
+			return err
+		}
+		defer ethClient.Close()
+
+		client = ethClient
+		if deployment, err = app.resolveContract(ctx, cfg, client); err != nil {
+			return err
+		}
+	}
+
+	// 確認合約地址上的代碼就是 SimpleStorage
+	readOnly := cfg.Server.ReadOnly
+	if err := contracts.VerifyCode(ctx, client, deployment.Address); err != nil {
+		if !errors.Is(err, contracts.ErrNoCode) && !errors.Is(err, contracts.ErrCodeMismatch) {
+			return err
+		}
+		if cfg.Contract.Verify != config.VerifyReadOnly {
+			return fmt.Errorf("refusing to serve: %v", err)
+		}
+		log.Printf("Warning: %v, serving read-only", err)
+		readOnly = true
+	}
+
+	// 只讀模式不需要在機器上存放私鑰，也不會解鎖 keystore
+	if !cfg.Dev.Enabled && !readOnly {
+		if !cfg.HasSigner() {
+			log.Printf("No signer configured, serving read-only")
+			readOnly = true
+		} else if txSigners, err = cfg.NewSigners(); err != nil {
+			return err
+		}
+	}
+	if readOnly {
+		txSigners = nil
+	}
+
+	// 創建合約交互器
+	interactor, err := contracts.NewContractInteractor(
+		client,
+		deployment.Address.Hex(),
+		txSigners...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create contract interactor: %v", err)
+	}
+
+	interactor.SetFeeStrategy(cfg.FeeStrategy())
+	interactor.SetGasLimitMargin(cfg.Gas.LimitMargin)
+	interactor.SetPoolOptions(cfg.PoolOptions())
+	go interactor.MonitorSenders(ctx)
+
+	// 在背景索引 DataStored 事件
+	var eventIndexer *indexer.Indexer
+	if cfg.Indexer.DB != "" {
+		chainID, err := client.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get chain id: %v", err)
+		}
+		store, err := indexer.OpenStore(cfg.Indexer.DB, fmt.Sprintf("%s:%s", chainID, deployment.Address.Hex()))
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		options := indexer.DefaultOptions()
+		options.StartBlock = cfg.Indexer.StartBlock
+		if options.StartBlock == 0 {
+			// 合約部署之前不會有事件，從部署所在區塊開始索引
+			options.StartBlock = deployment.BlockNumber
+		}
+		options.Confirmations = cfg.Indexer.Confirmations
+		eventIndexer, err = indexer.New(client, deployment.Address, store, options)
+		if err != nil {
+			return fmt.Errorf("failed to create event indexer: %v", err)
+		}
+		go eventIndexer.Run(ctx)
+	}
+
+	// 創建 API handler
+	handler := api.NewStorageHandler(interactor)
+	handler.SetWaitTimeout(time.Duration(cfg.Timeouts.TxWait))
+	txHandler := api.NewTxHandler(interactor)
+	historyHandler := api.NewHistoryHandler(eventIndexer)
+	// 認證關閉時所有接口本來就是公開的，不檢查 WebSocket 的來源
+	allowedOrigins := cfg.Auth.AllowedOrigins
+	if !cfg.Auth.Enabled {
+		allowedOrigins = []string{api.AnyOrigin}
+	}
+	eventsHandler := api.NewEventsHandler(eventIndexer, allowedOrigins)
+
+	// 按權限檢查 API 密鑰和 JWT
+	var authenticator *api.Authenticator
+	if cfg.Auth.Enabled {
+		keys, err := cfg.OpenKeyStore()
+		if err != nil {
+			return err
+		}
+		if len(keys.List()) == 0 && cfg.Auth.JWTSecret == "" {
+			log.Printf("No API keys in %s and no AUTH_JWT_SECRET, only %v is allowed; create a key with abby apikey new", cfg.Auth.KeysFile, cfg.Auth.Anonymous)
+		}
+		authenticator = api.NewAuthenticator(keys, cfg.JWTVerifier(), cfg.Auth.Anonymous)
+	} else {
+		log.Printf("Authentication is disabled, every route is open")
+	}
+
+	// 按客戶端限流，發送交易的路由另外檢查每日 gas 預算
+	var budget *quota.Budget
+	if limit := cfg.DailyGasBudget(); limit != nil && !interactor.ReadOnly() {
+		if cfg.Limits.BudgetDB != "" {
+			budget, err = quota.OpenBudget(cfg.Limits.BudgetDB, limit, interactor)
+			if err != nil {
+				return err
+			}
+			defer budget.Close()
+		} else {
+			budget = quota.NewBudget(limit, interactor)
+			log.Printf("Gas budget is kept in memory only, a restart resets it")
+		}
+		go budget.Run(ctx)
+		log.Printf("Daily gas budget per client: %f ETH", contracts.WeiToEth(limit))
+	}
+	limiter := api.NewLimiter(cfg.RateLimiter(), budget)
+
+	// 記住發送了交易的 Idempotency-Key 請求，客戶端重試時不會重複發送
+	var idempotent *api.Idempotency
+	if cfg.Idempotency.DB != "" && !interactor.ReadOnly() {
+		store, err := idempotency.OpenStore(cfg.Idempotency.DB, time.Duration(cfg.Idempotency.TTL))
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		go store.Run(ctx)
+		idempotent = api.NewIdempotency(store)
+	}
+
+	// 設置路由
+	router := api.SetupRouter(authenticator, limiter, idempotent, handler, txHandler, historyHandler, eventsHandler)
+
+	// 啟動服務器
+	baseURL := serverURL(cfg.Server.ListenAddr)
+	fmt.Printf("Server is running on %s\n", baseURL)
+	fmt.Printf("Swagger UI is available at %s/swagger/index.html\n", baseURL)
+	server := &http.Server{
+		Addr:    cfg.Server.ListenAddr,
+		Handler: router,
+		// 請求的 context 隨 ctx 取消，SSE 和 WebSocket 連接也能及時結束
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		// 收到中斷信號時關閉服務器，讓索引和模擬鏈正常退出
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start server: %v", err)
+	}
+	return nil
+}
+
+// serverURL 將監聽地址轉換為可訪問的 URL，未指定主機時使用 localhost
+func serverURL(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return "http://" + listenAddr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
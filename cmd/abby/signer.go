@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"Abby/config"
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// runSigner 運行與 Clef 兼容的本地簽名服務，用配置的私鑰或 keystore 簽名
+// 只用於在沒有 Clef 的環境中測試 remote 簽名者
+func runSigner(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("signer", "")
+	listen := fs.String("signer.listen", "127.0.0.1:8550", "listen address of the signer JSON-RPC endpoint")
+	deny := fs.Bool("deny", false, "reject every signing request, to test how clients handle rejections")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := app.loader.Load(config.Requirements{Signer: true, Offline: true})
+	if err != nil {
+		return err
+	}
+	if cfg.Signer.Type == config.SignerRemote {
+		return errors.New("abby signer needs a key or keystore signer to sign with")
+	}
+	txSigner, err := cfg.NewSigner()
+	if err != nil {
+		return err
+	}
+
+	rpcServer, err := signer.NewRPCServer(txSigner, func(*apitypes.SendTxArgs) bool { return !*deny })
+	if err != nil {
+		return err
+	}
+	defer rpcServer.Stop()
+
+	fmt.Printf("Signer for %s is running on %s\n", txSigner.Address().Hex(), serverURL(*listen))
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: rpcServer,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to start signer: %v", err)
+	}
+	return nil
+}
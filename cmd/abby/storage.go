@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"Abby/config"
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// valueResult get 命令的 JSON 輸出
+type valueResult struct {
+	Contract    string `json:"contract"`
+	Value       string `json:"value"`
+	BlockNumber uint64 `json:"blockNumber"`
+	BlockHash   string `json:"blockHash,omitempty"`
+}
+
+// setResult set 命令的 JSON 輸出
+type setResult struct {
+	TxHash      string  `json:"txHash"`
+	Nonce       uint64  `json:"nonce"`
+	Status      string  `json:"status"`
+	BlockNumber *uint64 `json:"blockNumber,omitempty"`
+	GasUsed     *uint64 `json:"gasUsed,omitempty"`
+}
+
+// eventResult watch 命令每個事件的 JSON 輸出
+type eventResult struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	TxHash      string `json:"txHash"`
+	LogIndex    uint   `json:"logIndex"`
+	Value       string `json:"value"`
+}
+
+func runGet(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("get", "")
+	block := fs.String("block", "latest", "block to read at: a number, a block hash or latest, pending, safe, finalized, earliest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ref, err := contracts.ParseBlockRef(*block)
+	if err != nil {
+		return err
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	deployment, err := app.resolveContract(ctx, cfg, client)
+	if err != nil {
+		return err
+	}
+	interactor, err := contracts.NewContractInteractor(client, deployment.Address.Hex())
+	if err != nil {
+		return err
+	}
+	value, err := interactor.GetValueAt(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	result := valueResult{
+		Contract:    deployment.Address.Hex(),
+		Value:       value.Value.String(),
+		BlockNumber: value.BlockNumber,
+	}
+	rows := []row{
+		{"Contract", deployment.Address.Hex()},
+		{"Value", value.Value},
+		{"Block", value.BlockNumber},
+	}
+	if value.BlockHash != (common.Hash{}) {
+		result.BlockHash = value.BlockHash.Hex()
+		rows = append(rows, row{"Block hash", result.BlockHash})
+	}
+	return app.printer().result(result, rows)
+}
+
+func runSet(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("set", "<value>")
+	wait := fs.Bool("wait", true, "wait for the transaction to be mined")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("set expects exactly one value")
+	}
+
+	// 將字符串轉換為 big.Int
+	value, ok := new(big.Int).SetString(fs.Arg(0), 10)
+	if !ok {
+		return fmt.Errorf("invalid number format: %q", fs.Arg(0))
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{Signer: true})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	deployment, err := app.resolveContract(ctx, cfg, client)
+	if err != nil {
+		return err
+	}
+	txSigner, err := cfg.NewSigner()
+	if err != nil {
+		return err
+	}
+	interactor, err := contracts.NewContractInteractor(client, deployment.Address.Hex(), txSigner)
+	if err != nil {
+		return err
+	}
+	interactor.SetFeeStrategy(cfg.FeeStrategy())
+	interactor.SetGasLimitMargin(cfg.Gas.LimitMargin)
+
+	tx, err := interactor.SendSetValue(ctx, value)
+	if err != nil {
+		return err
+	}
+
+	result := setResult{
+		TxHash: tx.Hash().Hex(),
+		Nonce:  tx.Nonce(),
+		Status: contracts.TxStatusPending,
+	}
+	if *wait {
+		waitCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeouts.TxWait))
+		defer cancel()
+		receipt, err := interactor.WaitMined(waitCtx, tx)
+		if err != nil {
+			return fmt.Errorf("%v (transaction %s)", err, tx.Hash().Hex())
+		}
+		blockNumber := receipt.BlockNumber.Uint64()
+		result.Status = contracts.TxStatusMined
+		result.BlockNumber = &blockNumber
+		result.GasUsed = &receipt.GasUsed
+	}
+
+	rows := []row{
+		{"Transaction", result.TxHash},
+		{"Nonce", result.Nonce},
+		{"Status", result.Status},
+	}
+	if result.BlockNumber != nil {
+		rows = append(rows, row{"Block", *result.BlockNumber}, row{"Gas used", *result.GasUsed})
+	}
+	return app.printer().result(result, rows)
+}
+
+func runWatch(ctx context.Context, app *app, args []string) error {
+	fs := app.flagSet("watch", "")
+	from := fs.String("from", "", "also print past events starting at this block")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var fromBlock *uint64
+	if *from != "" {
+		block, err := strconv.ParseUint(*from, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid --from block: %q", *from)
+		}
+		fromBlock = &block
+	}
+
+	cfg, client, closeClient, err := app.connect(ctx, config.Requirements{})
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	deployment, err := app.resolveContract(ctx, cfg, client)
+	if err != nil {
+		return err
+	}
+	interactor, err := contracts.NewContractInteractor(client, deployment.Address.Hex())
+	if err != nil {
+		return err
+	}
+
+	out := app.printer()
+	header := fmt.Sprintf("%-10s  %-66s  %-5s  %s", "BLOCK", "TRANSACTION", "LOG", "VALUE")
+	return interactor.WatchEvents(ctx, fromBlock, func(event *contracts.ContractsDataStored) {
+		raw := event.Raw
+		line := fmt.Sprintf("%-10d  %-66s  %-5d  %s", raw.BlockNumber, raw.TxHash.Hex(), raw.Index, event.NewValue)
+		out.stream(eventResult{
+			BlockNumber: raw.BlockNumber,
+			TxHash:      raw.TxHash.Hex(),
+			LogIndex:    raw.Index,
+			Value:       event.NewValue.String(),
+		}, header, line)
+	})
+}
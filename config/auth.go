@@ -0,0 +1,18 @@
+package config
+
+import (
+	"Abby/auth"
+)
+
+// OpenKeyStore 打開配置的 API 密鑰文件
+func (c *Config) OpenKeyStore() (*auth.KeyStore, error) {
+	return auth.OpenKeyStore(c.Auth.KeysFile)
+}
+
+// JWTVerifier 返回 JWT 驗證器，沒有設置 AUTH_JWT_SECRET 時返回 nil
+func (c *Config) JWTVerifier() *auth.JWTVerifier {
+	if c.Auth.JWTSecret == "" {
+		return nil
+	}
+	return auth.NewJWTVerifier([]byte(c.Auth.JWTSecret), c.Auth.JWTIssuer)
+}
@@ -0,0 +1,553 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"Abby/auth"
+	"Abby/contracts"
+	"Abby/idempotency"
+	"Abby/quota"
+	"Abby/registry"
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 合約代碼校驗失敗時服務的處理方式
+const (
+	// VerifyStrict 拒絕啟動
+	VerifyStrict = "strict"
+	// VerifyReadOnly 以只讀模式啟動，不發送交易
+	VerifyReadOnly = "readonly"
+)
+
+// 簽名來源
+const (
+	// SignerKey 使用配置或 PRIVATE_KEY 中的十六進制私鑰
+	SignerKey = "key"
+	// SignerKeystore 使用 go-ethereum 格式的加密 keystore 文件，以密碼解鎖
+	SignerKeystore = "keystore"
+	// SignerRemote 通過 JSON-RPC 交給外部簽名者 (Clef) 簽名，進程內不持有私鑰
+	SignerRemote = "remote"
+	// SignerMnemonic 從 BIP-39 助記詞按派生路徑得到部署帳戶和發送帳戶
+	SignerMnemonic = "mnemonic"
+)
+
+// knownChainIDs 已知網絡的鏈 ID，未設置 chainId 時按網絡名稱補上
+var knownChainIDs = map[string]uint64{
+	"mainnet": 1,
+	"sepolia": 11155111,
+	"holesky": 17000,
+}
+
+// Config 服務和部署工具共用的配置
+type Config struct {
+	Network     NetworkConfig     `yaml:"network" toml:"network"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Contract    ContractConfig    `yaml:"contract" toml:"contract"`
+	Signer      SignerConfig      `yaml:"signer" toml:"signer"`
+	Senders     SendersConfig     `yaml:"senders" toml:"senders"`
+	Gas         GasConfig         `yaml:"gas" toml:"gas"`
+	Timeouts    TimeoutConfig     `yaml:"timeouts" toml:"timeouts"`
+	Indexer     IndexerConfig     `yaml:"indexer" toml:"indexer"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Limits      LimitsConfig      `yaml:"limits" toml:"limits"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Dev         DevConfig         `yaml:"dev" toml:"dev"`
+}
+
+// NetworkConfig 要連接的鏈
+type NetworkConfig struct {
+	// Name 網絡名稱，用於補上已知的鏈 ID 和由 INFURA_API_KEY 生成 RPC URL
+	Name string `yaml:"name" toml:"name"`
+	// RPCURLs RPC 節點，按順序嘗試，第一個可用的節點會被使用
+	RPCURLs []string `yaml:"rpcUrls" toml:"rpcUrls"`
+	// ChainID 預期的鏈 ID，連接後會校驗，0 表示不校驗
+	ChainID uint64 `yaml:"chainId" toml:"chainId"`
+}
+
+// ServerConfig API 服務
+type ServerConfig struct {
+	// ListenAddr 監聽地址
+	ListenAddr string `yaml:"listenAddr" toml:"listenAddr"`
+	// ReadOnly 即使配置了簽名者也不發送交易
+	ReadOnly bool `yaml:"readOnly" toml:"readOnly"`
+}
+
+// ContractConfig 已部署的合約
+type ContractConfig struct {
+	// Address 明確指定的合約地址，為空時使用部署記錄中當前鏈的地址
+	Address string `yaml:"address" toml:"address"`
+	// Registry 部署記錄文件
+	Registry string `yaml:"registry" toml:"registry"`
+	// Verify 服務啟動時合約地址上沒有代碼或代碼不符時的處理方式
+	Verify string `yaml:"verify" toml:"verify"`
+}
+
+// SignerConfig 交易簽名來源
+type SignerConfig struct {
+	Type       string `yaml:"type" toml:"type"`
+	PrivateKey string `yaml:"privateKey" toml:"privateKey"`
+	// PrivateKeys 其他發送帳戶的私鑰，與 PrivateKey 一起組成帳戶池
+	PrivateKeys []string `yaml:"privateKeys" toml:"privateKeys"`
+	// Keystore keystore 目錄，Account 和 Accounts 都為空時目錄中只能有一個帳戶
+	Keystore string `yaml:"keystore" toml:"keystore"`
+	Account  string `yaml:"account" toml:"account"`
+	// Accounts 其他發送帳戶，keystore 帳戶使用相同的密碼解鎖
+	Accounts     []string `yaml:"accounts" toml:"accounts"`
+	PasswordFile string   `yaml:"passwordFile" toml:"passwordFile"`
+	// Password 只能用 KEYSTORE_PASSWORD 環境變量設置，不從配置文件讀取
+	Password string `yaml:"-" toml:"-"`
+	// URL 外部簽名者的 JSON-RPC 地址，Timeout 為每個簽名請求等待批准的最長時間
+	URL     string   `yaml:"url" toml:"url"`
+	Timeout Duration `yaml:"timeout" toml:"timeout"`
+	// MnemonicFile BIP-39 助記詞文件，MnemonicPassword 為可選的 BIP-39 密碼，只能用 MNEMONIC_PASSWORD 設置
+	MnemonicFile     string `yaml:"mnemonicFile" toml:"mnemonicFile"`
+	MnemonicPassword string `yaml:"-" toml:"-"`
+	// DeployerPath 部署帳戶的派生路徑，SenderPaths 為發送帳戶的派生路徑，為空時使用 DeployerPath
+	// 不以 m 開頭的路徑接在 m/44'/60'/0'/0 之後，例如 "3" 表示 m/44'/60'/0'/0/3
+	DeployerPath string   `yaml:"deployerPath" toml:"deployerPath"`
+	SenderPaths  []string `yaml:"senderPaths" toml:"senderPaths"`
+}
+
+// SendersConfig 有多個發送帳戶時的分配策略和健康檢查
+type SendersConfig struct {
+	// Strategy 分配寫入的策略：least-pending 或 round-robin
+	Strategy string `yaml:"strategy" toml:"strategy"`
+	// MinBalanceWei 餘額低於此值 (wei) 的帳戶暫停使用，0 表示不檢查
+	MinBalanceWei string `yaml:"minBalanceWei" toml:"minBalanceWei"`
+	// StuckAfter 交易超過此時間未打包時暫停使用該帳戶，0 表示不檢查
+	StuckAfter    Duration `yaml:"stuckAfter" toml:"stuckAfter"`
+	CheckInterval Duration `yaml:"checkInterval" toml:"checkInterval"`
+}
+
+// GasConfig 交易費用和 gas limit 設置，費用以 wei 為單位的十進制字符串表示
+type GasConfig struct {
+	BaseFeeMultiplier float64 `yaml:"baseFeeMultiplier" toml:"baseFeeMultiplier"`
+	MaxFeeWei         string  `yaml:"maxFeeWei" toml:"maxFeeWei"`
+	MaxPriorityFeeWei string  `yaml:"maxPriorityFeeWei" toml:"maxPriorityFeeWei"`
+	LimitMargin       float64 `yaml:"limitMargin" toml:"limitMargin"`
+}
+
+// TimeoutConfig 超時設置
+type TimeoutConfig struct {
+	// RPC 連接節點和啟動時查詢鏈狀態的超時
+	RPC Duration `yaml:"rpc" toml:"rpc"`
+	// TxWait 以 wait=true 發送交易時等待確認的最長時間
+	TxWait Duration `yaml:"txWait" toml:"txWait"`
+}
+
+// AuthConfig API 認證，寫入接口需要 storage:write 權限
+type AuthConfig struct {
+	// Enabled 為 false 時所有接口都不需要認證
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// KeysFile API 密鑰文件，只保存密鑰的哈希，由 abby apikey 管理
+	KeysFile string `yaml:"keysFile" toml:"keysFile"`
+	// JWTSecret HS256 JWT 的簽名密鑰，為空時不接受 JWT，只能用 AUTH_JWT_SECRET 設置
+	JWTSecret string `yaml:"-" toml:"-"`
+	// JWTIssuer 不為空時要求 JWT 的 iss 與之相同
+	JWTIssuer string `yaml:"jwtIssuer" toml:"jwtIssuer"`
+	// Anonymous 不帶憑據的請求擁有的權限
+	Anonymous []string `yaml:"anonymous" toml:"anonymous"`
+	// AllowedOrigins 除同源頁面外可以連接事件 WebSocket 的網頁來源，例如 https://app.example.com，"*" 允許所有來源
+	// 認證關閉時不檢查來源
+	AllowedOrigins []string `yaml:"allowedOrigins" toml:"allowedOrigins"`
+}
+
+// LimitsConfig 每個客戶端的限流和每日 gas 預算，客戶端按 API 密鑰、JWT subject 或匿名請求的 IP 區分
+type LimitsConfig struct {
+	// Routes 每個路由的令牌桶，鍵為 "POST /storage/value" 這樣的方法和路徑，沒有列出的路由不限流
+	Routes map[string]RouteLimit `yaml:"routes" toml:"routes"`
+	// DailyGasBudgetWei 每個客戶端每天 (UTC) 可以花費的 gas 費用 (wei)，為空或 0 表示不限制
+	DailyGasBudgetWei string `yaml:"dailyGasBudgetWei" toml:"dailyGasBudgetWei"`
+	// BudgetDB 保存當天花費和預留的文件，為空表示只保存在內存中，重啟後重置
+	BudgetDB string `yaml:"budgetDB" toml:"budgetDB"`
+}
+
+// RouteLimit 一個路由的令牌桶，Rate 為 "10/s"、"30/m" 或 "100/h"，為空表示不限流
+// Burst 為 0 時取每個時間單位的請求數
+type RouteLimit struct {
+	Rate  string `yaml:"rate" toml:"rate"`
+	Burst int    `yaml:"burst" toml:"burst"`
+}
+
+// IdempotencyConfig Idempotency-Key 記錄
+type IdempotencyConfig struct {
+	// DB 記錄文件路徑，為空表示忽略 Idempotency-Key
+	DB string `yaml:"db" toml:"db"`
+	// TTL 記錄保存的時間，之後同一個鍵可以用於新的請求
+	TTL Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// IndexerConfig 事件索引
+type IndexerConfig struct {
+	// DB 索引文件路徑，為空表示不啟用索引
+	DB            string `yaml:"db" toml:"db"`
+	StartBlock    uint64 `yaml:"startBlock" toml:"startBlock"`
+	Confirmations uint64 `yaml:"confirmations" toml:"confirmations"`
+}
+
+// DevConfig 進程內模擬鏈，啟用時忽略網絡、合約和簽名設置
+type DevConfig struct {
+	Enabled   bool     `yaml:"enabled" toml:"enabled"`
+	BlockTime Duration `yaml:"blockTime" toml:"blockTime"`
+	Accounts  int      `yaml:"accounts" toml:"accounts"`
+	// Senders 前幾個帳戶作為發送帳戶池
+	Senders int `yaml:"senders" toml:"senders"`
+}
+
+// Duration 可從 "30s"、"2m" 這樣的字符串讀取的時間間隔
+type Duration time.Duration
+
+// UnmarshalText 解析 time.ParseDuration 格式的字符串
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 輸出 time.Duration 的字符串格式
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Default 返回預設配置，與之前的行為保持一致：Sepolia 測試網、監聽 :8081
+func Default() *Config {
+	fees := contracts.DefaultFeeStrategy()
+	deploy := contracts.DefaultDeployOptions()
+	pool := contracts.DefaultPoolOptions()
+	return &Config{
+		Network: NetworkConfig{
+			Name: "sepolia",
+		},
+		Server: ServerConfig{
+			ListenAddr: ":8081",
+		},
+		Contract: ContractConfig{
+			Registry: registry.DefaultFile,
+			Verify:   VerifyStrict,
+		},
+		Signer: SignerConfig{
+			Type:         SignerKey,
+			Keystore:     "keystore",
+			Timeout:      Duration(2 * time.Minute),
+			DeployerPath: signer.DefaultHDBasePath + "/0",
+		},
+		Senders: SendersConfig{
+			Strategy:      pool.Strategy,
+			MinBalanceWei: pool.MinBalance.String(),
+			StuckAfter:    Duration(pool.StuckAfter),
+			CheckInterval: Duration(pool.CheckInterval),
+		},
+		Gas: GasConfig{
+			BaseFeeMultiplier: fees.BaseFeeMultiplier,
+			LimitMargin:       deploy.GasLimitMargin,
+		},
+		Timeouts: TimeoutConfig{
+			RPC:    Duration(30 * time.Second),
+			TxWait: Duration(5 * time.Minute),
+		},
+		Indexer: IndexerConfig{
+			DB: "events.db",
+		},
+		Auth: AuthConfig{
+			Enabled:   true,
+			KeysFile:  auth.DefaultKeysFile,
+			Anonymous: []string{auth.ScopeStorageRead},
+		},
+		Limits: LimitsConfig{
+			Routes: map[string]RouteLimit{
+				"GET /storage/value":     {Rate: "20/s", Burst: 40},
+				"GET /storage/history":   {Rate: "10/s", Burst: 20},
+				"GET /storage/events":    {Rate: "1/s", Burst: 5},
+				"GET /storage/events/ws": {Rate: "1/s", Burst: 5},
+				"POST /storage/value":    {Rate: "1/s", Burst: 5},
+				"POST /tx/:hash/speedup": {Rate: "1/s", Burst: 5},
+				"POST /tx/:hash/cancel":  {Rate: "1/s", Burst: 5},
+			},
+			BudgetDB: "budget.db",
+		},
+		Idempotency: IdempotencyConfig{
+			DB:  "idempotency.db",
+			TTL: Duration(idempotency.DefaultTTL),
+		},
+		Dev: DevConfig{
+			Accounts: 10,
+			Senders:  1,
+		},
+	}
+}
+
+// Requirements 各個命令必須設置的配置項
+// 合約地址要在連接節點、知道鏈 ID 之後才能從部署記錄確定，見 ResolveContract
+type Requirements struct {
+	Signer bool
+	// Offline 命令不連接節點，跳過網絡和合約的檢查
+	Offline bool
+}
+
+// Validate 檢查配置，返回包含所有問題的錯誤
+func (c *Config) Validate(req Requirements) error {
+	var errs []error
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Dev.Enabled {
+		if c.Dev.Accounts < 1 {
+			invalid("dev.accounts", "must be at least 1, got %d", c.Dev.Accounts)
+		}
+		if c.Dev.Senders < 1 || c.Dev.Senders > c.Dev.Accounts {
+			invalid("dev.senders", "must be between 1 and dev.accounts (%d), got %d", c.Dev.Accounts, c.Dev.Senders)
+		}
+		if c.Dev.BlockTime < 0 {
+			invalid("dev.blockTime", "must not be negative")
+		}
+	} else {
+		if !req.Offline {
+			if len(c.Network.RPCURLs) == 0 {
+				invalid("network.rpcUrls", "at least one RPC URL is required (set RPC_URL, INFURA_API_KEY, network.rpcUrls or --rpc.url)")
+			}
+			for _, raw := range c.Network.RPCURLs {
+				if err := validateRPCURL(raw); err != nil {
+					invalid("network.rpcUrls", "%s: %v", redactURL(raw), err)
+				}
+			}
+
+			if c.Contract.Address != "" && !common.IsHexAddress(c.Contract.Address) {
+				invalid("contract.address", "%q is not a valid address", c.Contract.Address)
+			}
+			if c.Contract.Registry == "" {
+				invalid("contract.registry", "required")
+			}
+			if c.Contract.Verify != VerifyStrict && c.Contract.Verify != VerifyReadOnly {
+				invalid("contract.verify", "unknown mode %q, supported: %s, %s", c.Contract.Verify, VerifyStrict, VerifyReadOnly)
+			}
+		}
+
+		switch c.Signer.Type {
+		case SignerKey:
+			if c.Signer.PrivateKey != "" {
+				if _, err := crypto.HexToECDSA(strings.TrimPrefix(c.Signer.PrivateKey, "0x")); err != nil {
+					invalid("signer.privateKey", "invalid private key")
+				}
+			} else if req.Signer && len(c.Signer.PrivateKeys) == 0 {
+				invalid("signer.privateKey", "required (set PRIVATE_KEY or signer.privateKey)")
+			}
+			for i, key := range c.Signer.PrivateKeys {
+				if _, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x")); err != nil {
+					invalid("signer.privateKeys", "key #%d is not a valid private key", i)
+				}
+			}
+		case SignerKeystore:
+			if c.Signer.Keystore == "" {
+				invalid("signer.keystore", "required")
+			}
+			c.validateAccounts(invalid)
+		case SignerRemote:
+			if c.Signer.URL == "" && req.Signer {
+				invalid("signer.url", "required (set SIGNER_URL or --signer.url)")
+			}
+			c.validateAccounts(invalid)
+			if c.Signer.Timeout <= 0 {
+				invalid("signer.timeout", "must be positive")
+			}
+		case SignerMnemonic:
+			if c.Signer.MnemonicFile == "" && req.Signer {
+				invalid("signer.mnemonicFile", "required (set SIGNER_MNEMONIC_FILE or --mnemonic.file)")
+			}
+			if _, err := accounts.ParseDerivationPath(c.Signer.DeployerPath); err != nil {
+				invalid("signer.deployerPath", "%v", err)
+			}
+			for _, path := range c.Signer.SenderPaths {
+				if _, err := accounts.ParseDerivationPath(path); err != nil {
+					invalid("signer.senderPaths", "%q: %v", path, err)
+				}
+			}
+		default:
+			invalid("signer.type", "unknown signer %q, supported: %s, %s, %s, %s", c.Signer.Type, SignerKey, SignerKeystore, SignerRemote, SignerMnemonic)
+		}
+	}
+
+	if _, err := auth.ParseScopes(c.Auth.Anonymous); err != nil {
+		invalid("auth.anonymous", "%v", err)
+	}
+	for _, origin := range c.Auth.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			invalid("auth.allowedOrigins", "%q: %v", origin, err)
+		}
+	}
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < auth.MinJWTSecretLength {
+		invalid("auth.jwtSecret", "must be at least %d bytes", auth.MinJWTSecretLength)
+	}
+
+	for route, limit := range c.Limits.Routes {
+		if err := validateRoute(route); err != nil {
+			invalid("limits.routes", "%v", err)
+		}
+		if limit.Rate == "" {
+			continue
+		}
+		if _, err := quota.ParseRate(limit.Rate, limit.Burst); err != nil {
+			invalid("limits.routes", "%s: %v", route, err)
+		}
+	}
+	if c.Limits.DailyGasBudgetWei != "" {
+		if v, ok := new(big.Int).SetString(c.Limits.DailyGasBudgetWei, 10); !ok || v.Sign() < 0 {
+			invalid("limits.dailyGasBudgetWei", "%q is not a non-negative integer", c.Limits.DailyGasBudgetWei)
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		invalid("idempotency.ttl", "must be positive")
+	}
+
+	if c.Senders.Strategy != contracts.AssignLeastPending && c.Senders.Strategy != contracts.AssignRoundRobin {
+		invalid("senders.strategy", "unknown strategy %q, supported: %s, %s", c.Senders.Strategy, contracts.AssignLeastPending, contracts.AssignRoundRobin)
+	}
+	if c.Senders.MinBalanceWei != "" {
+		if v, ok := new(big.Int).SetString(c.Senders.MinBalanceWei, 10); !ok || v.Sign() < 0 {
+			invalid("senders.minBalanceWei", "%q is not a non-negative integer", c.Senders.MinBalanceWei)
+		}
+	}
+	if c.Senders.StuckAfter < 0 {
+		invalid("senders.stuckAfter", "must not be negative")
+	}
+	if c.Senders.CheckInterval <= 0 {
+		invalid("senders.checkInterval", "must be positive")
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.ListenAddr); err != nil {
+		invalid("server.listenAddr", "%q is not a host:port address", c.Server.ListenAddr)
+	}
+
+	if c.Gas.BaseFeeMultiplier < 1 {
+		invalid("gas.baseFeeMultiplier", "must be at least 1, got %v", c.Gas.BaseFeeMultiplier)
+	}
+	if c.Gas.MaxFeeWei != "" {
+		if v, ok := new(big.Int).SetString(c.Gas.MaxFeeWei, 10); !ok || v.Sign() <= 0 {
+			invalid("gas.maxFeeWei", "%q is not a positive integer", c.Gas.MaxFeeWei)
+		}
+	}
+	if c.Gas.MaxPriorityFeeWei != "" {
+		if v, ok := new(big.Int).SetString(c.Gas.MaxPriorityFeeWei, 10); !ok || v.Sign() < 0 {
+			invalid("gas.maxPriorityFeeWei", "%q is not a non-negative integer", c.Gas.MaxPriorityFeeWei)
+		}
+	}
+	if c.Gas.LimitMargin < 1 {
+		invalid("gas.limitMargin", "must be at least 1, got %v", c.Gas.LimitMargin)
+	}
+
+	if c.Timeouts.RPC <= 0 {
+		invalid("timeouts.rpc", "must be positive")
+	}
+	if c.Timeouts.TxWait <= 0 {
+		invalid("timeouts.txWait", "must be positive")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// FeeStrategy 返回配置的費用策略，應在 Validate 之後調用
+func (c *Config) FeeStrategy() contracts.FeeStrategy {
+	strategy := contracts.DefaultFeeStrategy()
+	strategy.BaseFeeMultiplier = c.Gas.BaseFeeMultiplier
+	if c.Gas.MaxFeeWei != "" {
+		strategy.MaxFeeCap, _ = new(big.Int).SetString(c.Gas.MaxFeeWei, 10)
+	}
+	if c.Gas.MaxPriorityFeeWei != "" {
+		strategy.MaxPriorityFee, _ = new(big.Int).SetString(c.Gas.MaxPriorityFeeWei, 10)
+	}
+	return strategy
+}
+
+// PoolOptions 返回配置的發送帳戶池選項，應在 Validate 之後調用
+func (c *Config) PoolOptions() contracts.PoolOptions {
+	opts := contracts.DefaultPoolOptions()
+	opts.Strategy = c.Senders.Strategy
+	opts.MinBalance = nil
+	if c.Senders.MinBalanceWei != "" {
+		opts.MinBalance, _ = new(big.Int).SetString(c.Senders.MinBalanceWei, 10)
+	}
+	opts.StuckAfter = time.Duration(c.Senders.StuckAfter)
+	opts.CheckInterval = time.Duration(c.Senders.CheckInterval)
+	return opts
+}
+
+// DeployOptions 返回配置的部署選項，應在 Validate 之後調用
+func (c *Config) DeployOptions() contracts.DeployOptions {
+	opts := contracts.DefaultDeployOptions()
+	opts.FeeStrategy = c.FeeStrategy()
+	opts.GasLimitMargin = c.Gas.LimitMargin
+	return opts
+}
+
+// validateAccounts 檢查 signer.account 和 signer.accounts 中的地址
+func (c *Config) validateAccounts(invalid func(field, format string, args ...any)) {
+	if c.Signer.Account != "" && !common.IsHexAddress(c.Signer.Account) {
+		invalid("signer.account", "%q is not a valid address", c.Signer.Account)
+	}
+	for _, account := range c.Signer.Accounts {
+		if !common.IsHexAddress(account) {
+			invalid("signer.accounts", "%q is not a valid address", account)
+		}
+	}
+}
+
+// validateOrigin 檢查網頁來源，格式與瀏覽器發送的 Origin 頭相同，沒有路徑
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("must look like https://app.example.com")
+	}
+	return nil
+}
+
+// validateRPCURL 檢查 RPC URL，支持 http(s)、ws(s) 和 IPC 文件路徑
+func validateRPCURL(raw string) error {
+	if strings.HasSuffix(raw, ".ipc") {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return errors.New("malformed URL")
+	}
+	switch u.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
+
+// redactURL 只保留 URL 的協議和主機，避免在日誌中洩露路徑或參數中的 API key
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "<rpc url>"
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"Abby/registry"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OpenRegistry 打開配置的部署記錄
+func (c *Config) OpenRegistry() (*registry.Registry, error) {
+	return registry.Open(c.Contract.Registry)
+}
+
+// ResolveContract 返回 chainID 上要使用的 SimpleStorage 部署
+// 明確配置的地址優先，其次是部署記錄中該鏈的記錄，最後導入舊的 contract_address.txt
+func (c *Config) ResolveContract(reg *registry.Registry, chainID uint64) (registry.Entry, error) {
+	entry, found := reg.Get(chainID, registry.SimpleStorage)
+
+	if c.Contract.Address != "" {
+		address := common.HexToAddress(c.Contract.Address)
+		if found && entry.Address == address {
+			return entry, nil
+		}
+		return registry.Entry{Address: address}, nil
+	}
+
+	if found {
+		log.Printf("Using %s %s on chain %d from %s", registry.SimpleStorage, entry.Address.Hex(), chainID, reg.Path())
+		if entry.ABIHash != (common.Hash{}) && entry.ABIHash != registry.ABIHash() {
+			log.Printf("Warning: %s was deployed with a different ABI than the current contract bindings", entry.Address.Hex())
+		}
+		return entry, nil
+	}
+
+	entry, imported, err := reg.ImportLegacy(chainID)
+	if err != nil {
+		return registry.Entry{}, err
+	}
+	if imported {
+		log.Printf("Imported %s from %s into %s for chain %d", entry.Address.Hex(), registry.LegacyFile, reg.Path(), chainID)
+		return entry, nil
+	}
+
+	return registry.Entry{}, fmt.Errorf("no %s deployment for chain %d in %s (run abby deploy, or set CONTRACT_ADDRESS, contract.address or --contract)", registry.SimpleStorage, chainID, reg.Path())
+}
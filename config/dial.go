@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Dial 按順序連接配置的 RPC 節點，返回第一個可用且鏈 ID 符合預期的節點
+func (c *Config) Dial(ctx context.Context) (*ethclient.Client, error) {
+	timeout := time.Duration(c.Timeouts.RPC)
+
+	var errs []error
+	for _, rawURL := range c.Network.RPCURLs {
+		client, err := dialOne(ctx, rawURL, timeout, c.Network.ChainID)
+		if err == nil {
+			log.Printf("Connected to %s (%s)", redactURL(rawURL), c.Network.Name)
+			return client, nil
+		}
+		var mismatch *ChainIDMismatchError
+		if errors.As(err, &mismatch) {
+			// 鏈 ID 不符是配置錯誤，不應退回到其他節點
+			return nil, err
+		}
+		// ethclient 的錯誤信息包含完整 URL，可能帶有 API key
+		msg := strings.ReplaceAll(err.Error(), rawURL, redactURL(rawURL))
+		errs = append(errs, fmt.Errorf("%s: %s", redactURL(rawURL), msg))
+	}
+	return nil, fmt.Errorf("failed to connect to any RPC endpoint:\n%v", errors.Join(errs...))
+}
+
+// ChainIDMismatchError 節點的鏈 ID 與配置不符
+type ChainIDMismatchError struct {
+	Endpoint string
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *ChainIDMismatchError) Error() string {
+	return fmt.Sprintf("%s reports chain id %d, expected %d", e.Endpoint, e.Actual, e.Expected)
+}
+
+func (e *ChainIDMismatchError) Unwrap() error {
+	return contracts.ErrWrongChain
+}
+
+func dialOne(ctx context.Context, rawURL string, timeout time.Duration, expectedChainID uint64) (*ethclient.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := ethclient.DialContext(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to get chain id: %v", err)
+	}
+	if expectedChainID != 0 && chainID.Uint64() != expectedChainID {
+		client.Close()
+		return nil, &ChainIDMismatchError{
+			Endpoint: redactURL(rawURL),
+			Expected: expectedChainID,
+			Actual:   chainID.Uint64(),
+		}
+	}
+	return client, nil
+}
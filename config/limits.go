@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"Abby/quota"
+)
+
+// RateLimiter 返回按配置限流的限流器，應在 Validate 之後調用
+func (c *Config) RateLimiter() *quota.RateLimiter {
+	rates := make(map[string]quota.Rate)
+	for route, limit := range c.Limits.Routes {
+		if limit.Rate == "" {
+			continue
+		}
+		rates[route], _ = quota.ParseRate(limit.Rate, limit.Burst)
+	}
+	return quota.NewRateLimiter(rates)
+}
+
+// DailyGasBudget 返回每個客戶端每天的 gas 預算 (wei)，沒有設置或為 0 時返回 nil
+func (c *Config) DailyGasBudget() *big.Int {
+	v, ok := new(big.Int).SetString(c.Limits.DailyGasBudgetWei, 10)
+	if !ok || v.Sign() == 0 {
+		return nil
+	}
+	return v
+}
+
+// validateRoute 檢查限流的路由是否為 "GET /storage/value" 這樣的方法和路徑
+func validateRoute(route string) error {
+	method, path, ok := strings.Cut(route, " ")
+	if !ok || (method != "GET" && method != "POST") || !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("route %q must look like \"POST /storage/value\"", route)
+	}
+	return nil
+}
+
+// setRoutes 解析 RATE_LIMITS 和 --limits.routes 的值，覆蓋其中列出的路由
+// 格式為逗號分隔的 "POST /storage/value=1/s:5"，冒號後為 burst，off 表示該路由不限流
+func (l *LimitsConfig) setRoutes(v string) error {
+	if l.Routes == nil {
+		l.Routes = make(map[string]RouteLimit)
+	}
+	for _, item := range splitList(v) {
+		route, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("%q must look like \"POST /storage/value=1/s:5\"", item)
+		}
+		route = strings.TrimSpace(route)
+		spec = strings.TrimSpace(spec)
+		if spec == "off" {
+			l.Routes[route] = RouteLimit{}
+			continue
+		}
+		rate, burst, ok := strings.Cut(spec, ":")
+		limit := RouteLimit{Rate: rate}
+		if ok {
+			n, err := strconv.Atoi(burst)
+			if err != nil {
+				return fmt.Errorf("%q has an invalid burst %q", item, burst)
+			}
+			limit.Burst = n
+		}
+		l.Routes[route] = limit
+	}
+	return nil
+}
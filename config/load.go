@@ -0,0 +1,421 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Loader 按 預設值 < 配置文件 < 環境變量 < 命令行參數 的優先級加載配置
+type Loader struct {
+	path string
+	// overrides 解析命令行參數時記錄的覆蓋，只包含明確設置過的參數
+	overrides []func(*Config)
+}
+
+// NewLoader 創建配置加載器，用 RegisterFlags 註冊命令行參數並在 Parse 之後調用 Load
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// RegisterFlags 在 fs 上註冊所有命令共用的參數：配置文件、網絡、合約、簽名、gas 和超時
+// 同一個 Loader 可以註冊到多個 FlagSet，例如全局參數和子命令參數
+func (l *Loader) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&l.path, "config", "", "path of a YAML or TOML config file (env ABBY_CONFIG)")
+
+	l.stringFlag(fs, "network", "network name, used for the expected chain id and Infura URL (default sepolia)", func(c *Config, v string) {
+		c.Network.Name = v
+	})
+	l.stringFlag(fs, "rpc.url", "RPC endpoint, comma separated for fallbacks (env RPC_URL)", func(c *Config, v string) {
+		c.Network.RPCURLs = splitList(v)
+	})
+	l.uintFlag(fs, "chain.id", "expected chain id, 0 skips the check (env CHAIN_ID)", func(c *Config, v uint64) {
+		c.Network.ChainID = v
+	})
+	l.stringFlag(fs, "contract", "SimpleStorage contract address (env CONTRACT_ADDRESS)", func(c *Config, v string) {
+		c.Contract.Address = v
+	})
+	l.stringFlag(fs, "registry", "deployment registry file (env DEPLOYMENTS_FILE, default deployments.json)", func(c *Config, v string) {
+		c.Contract.Registry = v
+	})
+	l.stringFlag(fs, "signer", "signer source: key, keystore, remote or mnemonic (env SIGNER, default key)", func(c *Config, v string) {
+		c.Signer.Type = v
+	})
+	l.stringFlag(fs, "keystore", "keystore directory (env SIGNER_KEYSTORE, default keystore)", func(c *Config, v string) {
+		c.Signer.Keystore = v
+	})
+	l.stringFlag(fs, "account", "keystore or remote signer account, required when the signer has several (env SIGNER_ACCOUNT)", func(c *Config, v string) {
+		c.Signer.Account = v
+	})
+	l.stringFlag(fs, "accounts", "more keystore or remote signer accounts for the sender pool, comma separated (env SIGNER_ACCOUNTS)", func(c *Config, v string) {
+		c.Signer.Accounts = splitList(v)
+	})
+	l.stringFlag(fs, "password.file", "file holding the keystore passphrase, otherwise KEYSTORE_PASSWORD or an interactive prompt (env SIGNER_PASSWORD_FILE)", func(c *Config, v string) {
+		c.Signer.PasswordFile = v
+	})
+	l.stringFlag(fs, "signer.url", "JSON-RPC endpoint of a Clef-compatible external signer (env SIGNER_URL)", func(c *Config, v string) {
+		c.Signer.URL = v
+	})
+	l.stringFlag(fs, "mnemonic.file", "file holding the BIP-39 mnemonic of the mnemonic signer (env SIGNER_MNEMONIC_FILE)", func(c *Config, v string) {
+		c.Signer.MnemonicFile = v
+	})
+	l.stringFlag(fs, "hd.deployer", "derivation path of the deployer account (env SIGNER_DEPLOYER_PATH, default m/44'/60'/0'/0/0)", func(c *Config, v string) {
+		c.Signer.DeployerPath = v
+	})
+	l.stringFlag(fs, "hd.senders", "derivation paths of the sender accounts, comma separated, default the deployer path (env SIGNER_SENDER_PATHS)", func(c *Config, v string) {
+		c.Signer.SenderPaths = splitList(v)
+	})
+	l.stringFlag(fs, "auth.keys", "API key file managed by abby apikey (env AUTH_KEYS_FILE, default apikeys.json)", func(c *Config, v string) {
+		c.Auth.KeysFile = v
+	})
+	l.durationFlag(fs, "signer.timeout", "how long to wait for the external signer to approve a request (env SIGNER_TIMEOUT, default 2m)", func(c *Config, v time.Duration) {
+		c.Signer.Timeout = Duration(v)
+	})
+	l.floatFlag(fs, "gas.multiplier", "max fee = base fee × multiplier + tip (env GAS_BASE_FEE_MULTIPLIER, default 2)", func(c *Config, v float64) {
+		c.Gas.BaseFeeMultiplier = v
+	})
+	l.stringFlag(fs, "gas.maxfee", "upper bound for the max fee per gas in wei (env GAS_MAX_FEE_WEI)", func(c *Config, v string) {
+		c.Gas.MaxFeeWei = v
+	})
+	l.stringFlag(fs, "gas.maxtip", "upper bound for the priority fee per gas in wei (env GAS_MAX_PRIORITY_FEE_WEI)", func(c *Config, v string) {
+		c.Gas.MaxPriorityFeeWei = v
+	})
+	l.floatFlag(fs, "gas.limitmargin", "gas limit = estimated gas × margin for deployments and writes (env GAS_LIMIT_MARGIN, default 1.2)", func(c *Config, v float64) {
+		c.Gas.LimitMargin = v
+	})
+	l.durationFlag(fs, "timeout.rpc", "timeout for connecting to the RPC endpoint (env RPC_TIMEOUT, default 30s)", func(c *Config, v time.Duration) {
+		c.Timeouts.RPC = Duration(v)
+	})
+	l.durationFlag(fs, "timeout.txwait", "how long to wait for a transaction receipt (env TX_WAIT_TIMEOUT, default 5m)", func(c *Config, v time.Duration) {
+		c.Timeouts.TxWait = Duration(v)
+	})
+}
+
+// RegisterServerFlags 在 fs 上註冊 API 服務專用的參數：監聽地址、事件索引和模擬鏈
+func (l *Loader) RegisterServerFlags(fs *flag.FlagSet) {
+	l.stringFlag(fs, "listen", "API listen address (env LISTEN_ADDR, default :8081)", func(c *Config, v string) {
+		c.Server.ListenAddr = v
+	})
+	fs.BoolFunc("readonly", "serve reads only and reject writes, even when a signer is configured (env READ_ONLY)", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		l.overrides = append(l.overrides, func(c *Config) { c.Server.ReadOnly = v })
+		return nil
+	})
+	fs.BoolFunc("auth", "require an API key or JWT with the right scope, --auth=false serves every route without authentication (env AUTH_ENABLED, default true)", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		l.overrides = append(l.overrides, func(c *Config) { c.Auth.Enabled = v })
+		return nil
+	})
+	l.stringFlag(fs, "auth.anonymous", "scopes granted to requests without credentials, comma separated, empty requires credentials everywhere (env AUTH_ANONYMOUS_SCOPES, default storage:read)", func(c *Config, v string) {
+		c.Auth.Anonymous = splitList(v)
+	})
+	l.stringFlag(fs, "auth.origins", `web origins besides the server's own that may open the event WebSocket, comma separated, "*" allows any (env AUTH_ALLOWED_ORIGINS)`, func(c *Config, v string) {
+		c.Auth.AllowedOrigins = splitList(v)
+	})
+	fs.Func("limits.routes", `per-client token buckets, comma separated "POST /storage/value=1/s:5", off disables a route (env RATE_LIMITS)`, func(s string) error {
+		// 先檢查格式，錯誤在解析參數時就報告
+		if err := new(LimitsConfig).setRoutes(s); err != nil {
+			return err
+		}
+		l.overrides = append(l.overrides, func(c *Config) { c.Limits.setRoutes(s) })
+		return nil
+	})
+	l.stringFlag(fs, "limits.gasbudget", "daily gas spend per client in wei, from gasUsed × effectiveGasPrice, empty or 0 disables the budget (env DAILY_GAS_BUDGET_WEI)", func(c *Config, v string) {
+		c.Limits.DailyGasBudgetWei = v
+	})
+	l.stringFlag(fs, "limits.budgetdb", "file keeping today's gas spend per client across restarts, empty keeps it in memory only so a restart resets it (env GAS_BUDGET_DB, default budget.db)", func(c *Config, v string) {
+		c.Limits.BudgetDB = v
+	})
+	l.stringFlag(fs, "idempotency.db", "file remembering Idempotency-Key requests, empty ignores the header (env IDEMPOTENCY_DB, default idempotency.db)", func(c *Config, v string) {
+		c.Idempotency.DB = v
+	})
+	l.durationFlag(fs, "idempotency.ttl", "how long an Idempotency-Key is remembered (env IDEMPOTENCY_TTL, default 24h)", func(c *Config, v time.Duration) {
+		c.Idempotency.TTL = Duration(v)
+	})
+	l.stringFlag(fs, "senders.strategy", "how writes are assigned to sender accounts: least-pending or round-robin (env SENDERS_STRATEGY, default least-pending)", func(c *Config, v string) {
+		c.Senders.Strategy = v
+	})
+	l.stringFlag(fs, "senders.minbalance", "take a sender out of rotation below this balance in wei, 0 disables the check (env SENDERS_MIN_BALANCE_WEI, default 0.001 ETH)", func(c *Config, v string) {
+		c.Senders.MinBalanceWei = v
+	})
+	l.durationFlag(fs, "senders.stuckafter", "take a sender out of rotation when its next transaction is pending this long, 0 disables the check (env SENDERS_STUCK_AFTER, default 5m)", func(c *Config, v time.Duration) {
+		c.Senders.StuckAfter = Duration(v)
+	})
+	l.stringFlag(fs, "contract.verify", "when the contract code is missing or wrong: strict refuses to start, readonly serves without sending transactions (env CONTRACT_VERIFY, default strict)", func(c *Config, v string) {
+		c.Contract.Verify = v
+	})
+	l.stringFlag(fs, "indexer.db", "path of the DataStored event index, empty disables indexing (default events.db)", func(c *Config, v string) {
+		c.Indexer.DB = v
+	})
+	l.uintFlag(fs, "indexer.start", "block to start indexing from when the index is empty", func(c *Config, v uint64) {
+		c.Indexer.StartBlock = v
+	})
+	l.uintFlag(fs, "indexer.confirmations", "only index blocks with at least this many confirmations", func(c *Config, v uint64) {
+		c.Indexer.Confirmations = v
+	})
+	fs.BoolFunc("dev", "run against an in-process simulated chain with an auto-deployed contract", func(s string) error {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		l.overrides = append(l.overrides, func(c *Config) { c.Dev.Enabled = v })
+		return nil
+	})
+	l.durationFlag(fs, "dev.blocktime", "block interval of the dev chain, 0 mines a block for every transaction", func(c *Config, v time.Duration) {
+		c.Dev.BlockTime = Duration(v)
+	})
+	l.uintFlag(fs, "dev.accounts", "number of prefunded accounts on the dev chain (default 10)", func(c *Config, v uint64) {
+		c.Dev.Accounts = int(v)
+	})
+	l.uintFlag(fs, "dev.senders", "number of dev accounts used as senders (default 1)", func(c *Config, v uint64) {
+		c.Dev.Senders = int(v)
+	})
+}
+
+func (l *Loader) stringFlag(fs *flag.FlagSet, name, usage string, set func(*Config, string)) {
+	fs.Func(name, usage, func(s string) error {
+		l.overrides = append(l.overrides, func(c *Config) { set(c, s) })
+		return nil
+	})
+}
+
+func (l *Loader) uintFlag(fs *flag.FlagSet, name, usage string, set func(*Config, uint64)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return errors.New("must be a non-negative integer")
+		}
+		l.overrides = append(l.overrides, func(c *Config) { set(c, v) })
+		return nil
+	})
+}
+
+func (l *Loader) floatFlag(fs *flag.FlagSet, name, usage string, set func(*Config, float64)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		l.overrides = append(l.overrides, func(c *Config) { set(c, v) })
+		return nil
+	})
+}
+
+func (l *Loader) durationFlag(fs *flag.FlagSet, name, usage string, set func(*Config, time.Duration)) {
+	fs.Func(name, usage, func(s string) error {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("must be a duration such as 30s")
+		}
+		l.overrides = append(l.overrides, func(c *Config) { set(c, v) })
+		return nil
+	})
+}
+
+// Load 加載配置並按 req 校驗
+func (l *Loader) Load(req Requirements) (*Config, error) {
+	// .env 中的值只在環境變量未設置時生效
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env: %v", err)
+	}
+
+	cfg := Default()
+
+	path := l.path
+	if path == "" {
+		path = os.Getenv("ABBY_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	for _, override := range l.overrides {
+		override(cfg)
+	}
+
+	resolve(cfg)
+	if err := cfg.Validate(req); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 按擴展名讀取 YAML 或 TOML 配置文件，未出現的項目保持原值
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("unsupported config file %s, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv 用環境變量覆蓋配置
+func applyEnv(cfg *Config) error {
+	var errs []error
+	str := func(name string, dst *string) {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			*dst = v
+		}
+	}
+	parsed := func(name string, parse func(string) error) {
+		if v := os.Getenv(name); v != "" {
+			if err := parse(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid value %q", name, v))
+			}
+		}
+	}
+
+	str("NETWORK", &cfg.Network.Name)
+	if v := os.Getenv("RPC_URL"); v != "" {
+		cfg.Network.RPCURLs = splitList(v)
+	}
+	parsed("CHAIN_ID", func(v string) (err error) {
+		cfg.Network.ChainID, err = strconv.ParseUint(v, 10, 64)
+		return err
+	})
+	str("LISTEN_ADDR", &cfg.Server.ListenAddr)
+	parsed("READ_ONLY", func(v string) (err error) {
+		cfg.Server.ReadOnly, err = strconv.ParseBool(v)
+		return err
+	})
+	parsed("AUTH_ENABLED", func(v string) (err error) {
+		cfg.Auth.Enabled, err = strconv.ParseBool(v)
+		return err
+	})
+	str("AUTH_KEYS_FILE", &cfg.Auth.KeysFile)
+	str("AUTH_JWT_SECRET", &cfg.Auth.JWTSecret)
+	str("AUTH_JWT_ISSUER", &cfg.Auth.JWTIssuer)
+	if v, ok := os.LookupEnv("AUTH_ANONYMOUS_SCOPES"); ok {
+		cfg.Auth.Anonymous = splitList(v)
+	}
+	if v, ok := os.LookupEnv("AUTH_ALLOWED_ORIGINS"); ok {
+		cfg.Auth.AllowedOrigins = splitList(v)
+	}
+	parsed("RATE_LIMITS", cfg.Limits.setRoutes)
+	str("DAILY_GAS_BUDGET_WEI", &cfg.Limits.DailyGasBudgetWei)
+	if v, ok := os.LookupEnv("GAS_BUDGET_DB"); ok {
+		cfg.Limits.BudgetDB = v
+	}
+	if v, ok := os.LookupEnv("IDEMPOTENCY_DB"); ok {
+		cfg.Idempotency.DB = v
+	}
+	parsed("IDEMPOTENCY_TTL", func(v string) error {
+		return cfg.Idempotency.TTL.UnmarshalText([]byte(v))
+	})
+	str("CONTRACT_ADDRESS", &cfg.Contract.Address)
+	str("DEPLOYMENTS_FILE", &cfg.Contract.Registry)
+	str("CONTRACT_VERIFY", &cfg.Contract.Verify)
+	str("SIGNER", &cfg.Signer.Type)
+	str("PRIVATE_KEY", &cfg.Signer.PrivateKey)
+	if v := os.Getenv("PRIVATE_KEYS"); v != "" {
+		cfg.Signer.PrivateKeys = splitList(v)
+	}
+	str("SIGNER_KEYSTORE", &cfg.Signer.Keystore)
+	str("SIGNER_ACCOUNT", &cfg.Signer.Account)
+	if v := os.Getenv("SIGNER_ACCOUNTS"); v != "" {
+		cfg.Signer.Accounts = splitList(v)
+	}
+	str("SIGNER_PASSWORD_FILE", &cfg.Signer.PasswordFile)
+	str("KEYSTORE_PASSWORD", &cfg.Signer.Password)
+	str("SIGNER_URL", &cfg.Signer.URL)
+	str("SIGNER_MNEMONIC_FILE", &cfg.Signer.MnemonicFile)
+	str("MNEMONIC_PASSWORD", &cfg.Signer.MnemonicPassword)
+	str("SIGNER_DEPLOYER_PATH", &cfg.Signer.DeployerPath)
+	if v := os.Getenv("SIGNER_SENDER_PATHS"); v != "" {
+		cfg.Signer.SenderPaths = splitList(v)
+	}
+	parsed("SIGNER_TIMEOUT", func(v string) error {
+		return cfg.Signer.Timeout.UnmarshalText([]byte(v))
+	})
+	str("SENDERS_STRATEGY", &cfg.Senders.Strategy)
+	str("SENDERS_MIN_BALANCE_WEI", &cfg.Senders.MinBalanceWei)
+	parsed("SENDERS_STUCK_AFTER", func(v string) error {
+		return cfg.Senders.StuckAfter.UnmarshalText([]byte(v))
+	})
+	parsed("GAS_BASE_FEE_MULTIPLIER", func(v string) (err error) {
+		cfg.Gas.BaseFeeMultiplier, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	str("GAS_MAX_FEE_WEI", &cfg.Gas.MaxFeeWei)
+	str("GAS_MAX_PRIORITY_FEE_WEI", &cfg.Gas.MaxPriorityFeeWei)
+	parsed("GAS_LIMIT_MARGIN", func(v string) (err error) {
+		cfg.Gas.LimitMargin, err = strconv.ParseFloat(v, 64)
+		return err
+	})
+	parsed("RPC_TIMEOUT", func(v string) error {
+		return cfg.Timeouts.RPC.UnmarshalText([]byte(v))
+	})
+	parsed("TX_WAIT_TIMEOUT", func(v string) error {
+		return cfg.Timeouts.TxWait.UnmarshalText([]byte(v))
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment:\n%v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// resolve 補上由其他配置推導出的值
+func resolve(cfg *Config) {
+	if cfg.Network.ChainID == 0 {
+		cfg.Network.ChainID = knownChainIDs[cfg.Network.Name]
+	}
+
+	if cfg.Dev.Enabled {
+		return
+	}
+
+	// 沒有設置 RPC URL 時，依次使用網絡專屬的 RPC URL 和 Infura
+	if len(cfg.Network.RPCURLs) == 0 && cfg.Network.Name != "" {
+		envName := strings.ToUpper(cfg.Network.Name) + "_RPC_URL"
+		if v := os.Getenv(envName); v != "" {
+			cfg.Network.RPCURLs = splitList(v)
+		} else if key := os.Getenv("INFURA_API_KEY"); key != "" {
+			cfg.Network.RPCURLs = []string{fmt.Sprintf("https://%s.infura.io/v3/%s", cfg.Network.Name, key)}
+		}
+	}
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
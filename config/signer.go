@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// HasSigner 返回是否配置了簽名者：私鑰、keystore、外部簽名者 URL 或助記詞文件
+func (c *Config) HasSigner() bool {
+	switch c.Signer.Type {
+	case SignerKey:
+		return c.Signer.PrivateKey != "" || len(c.Signer.PrivateKeys) > 0
+	case SignerRemote:
+		return c.Signer.URL != ""
+	case SignerMnemonic:
+		return c.Signer.MnemonicFile != ""
+	default:
+		return true
+	}
+}
+
+// NewSigner 按 signer.type 創建第一個發送帳戶的簽名者，用於部署等只需要一個帳戶的命令
+// 應在 Validate 之後調用
+func (c *Config) NewSigner() (signer.Signer, error) {
+	signers, err := c.newSigners(1)
+	if err != nil {
+		return nil, err
+	}
+	return signers[0], nil
+}
+
+// NewDeployer 創建部署合約使用的簽名者，助記詞簽名者使用 signer.deployerPath，其他簽名者與 NewSigner 相同
+func (c *Config) NewDeployer() (signer.Signer, error) {
+	if c.Signer.Type != SignerMnemonic {
+		return c.NewSigner()
+	}
+	wallet, err := c.HDWallet()
+	if err != nil {
+		return nil, err
+	}
+	path, err := accounts.ParseDerivationPath(c.Signer.DeployerPath)
+	if err != nil {
+		return nil, fmt.Errorf("signer.deployerPath: %v", err)
+	}
+	return wallet.Signer(path)
+}
+
+// NewSigners 按 signer.type 創建所有發送帳戶的簽名者，應在 Validate 之後調用
+// keystore 簽名者的密碼按 signer.passwordFile、KEYSTORE_PASSWORD、交互式輸入的順序獲取，所有帳戶共用
+func (c *Config) NewSigners() ([]signer.Signer, error) {
+	return c.newSigners(0)
+}
+
+// newSigners 創建最多 limit 個簽名者，limit 為 0 表示不限
+func (c *Config) newSigners(limit int) ([]signer.Signer, error) {
+	first := func(n int) int {
+		if limit > 0 && n > limit {
+			return limit
+		}
+		return n
+	}
+
+	switch c.Signer.Type {
+	case SignerKey:
+		keys := c.Signer.PrivateKeys
+		if c.Signer.PrivateKey != "" {
+			keys = append([]string{c.Signer.PrivateKey}, keys...)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("signer.privateKey: required (set PRIVATE_KEY or signer.privateKey)")
+		}
+		signers := make([]signer.Signer, 0, len(keys))
+		for _, key := range keys[:first(len(keys))] {
+			s, err := signer.NewHexKeySigner(key)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, s)
+		}
+		return signers, nil
+
+	case SignerKeystore:
+		ks := c.Keystore()
+		accounts := c.SignerAccounts()
+		if len(accounts) == 0 {
+			// 沒有指定帳戶時使用 keystore 中唯一的帳戶
+			account, err := ks.Find(nil)
+			if err != nil {
+				return nil, fmt.Errorf("%v (keystore %s)", err, c.Signer.Keystore)
+			}
+			accounts = []common.Address{account.Address}
+		}
+		accounts = accounts[:first(len(accounts))]
+
+		prompt := fmt.Sprintf("Passphrase for %s: ", accounts[0].Hex())
+		if len(accounts) > 1 {
+			prompt = "Passphrase for the keystore accounts: "
+		}
+		passphrase, err := c.Passphrase().Read(prompt)
+		if err != nil {
+			return nil, err
+		}
+		signers := make([]signer.Signer, 0, len(accounts))
+		for _, address := range accounts {
+			account, err := ks.Find(&address)
+			if err != nil {
+				return nil, fmt.Errorf("%v (keystore %s)", err, c.Signer.Keystore)
+			}
+			s, err := ks.Unlock(account, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, s)
+		}
+		return signers, nil
+
+	case SignerRemote:
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeouts.RPC))
+		defer cancel()
+		accounts := c.SignerAccounts()
+		if len(accounts) == 0 {
+			// 沒有指定帳戶時向簽名者查詢
+			s, err := signer.DialRemote(ctx, c.Signer.URL, nil, time.Duration(c.Signer.Timeout))
+			if err != nil {
+				return nil, err
+			}
+			return []signer.Signer{s}, nil
+		}
+		signers := make([]signer.Signer, 0, len(accounts))
+		for _, address := range accounts[:first(len(accounts))] {
+			s, err := signer.DialRemote(ctx, c.Signer.URL, &address, time.Duration(c.Signer.Timeout))
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, s)
+		}
+		return signers, nil
+
+	case SignerMnemonic:
+		wallet, err := c.HDWallet()
+		if err != nil {
+			return nil, err
+		}
+		paths := c.Signer.SenderPaths
+		if len(paths) == 0 {
+			paths = []string{c.Signer.DeployerPath}
+		}
+		signers := make([]signer.Signer, 0, len(paths))
+		for _, raw := range paths[:first(len(paths))] {
+			path, err := accounts.ParseDerivationPath(raw)
+			if err != nil {
+				return nil, fmt.Errorf("signer.senderPaths: %v", err)
+			}
+			s, err := wallet.Signer(path)
+			if err != nil {
+				return nil, err
+			}
+			signers = append(signers, s)
+		}
+		return signers, nil
+
+	default:
+		return nil, fmt.Errorf("signer.type: unknown signer %q", c.Signer.Type)
+	}
+}
+
+// DeployerAddress 返回部署帳戶的地址，keystore 帳戶不需要解鎖
+// 外部簽名者設置了帳戶時直接返回，否則需要向簽名者查詢
+func (c *Config) DeployerAddress() (common.Address, error) {
+	accounts := c.SignerAccounts()
+	switch {
+	case (c.Signer.Type == SignerKeystore || c.Signer.Type == SignerRemote) && len(accounts) > 0:
+		return accounts[0], nil
+	case c.Signer.Type == SignerKeystore:
+		account, err := c.Keystore().Find(nil)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("%v (keystore %s)", err, c.Signer.Keystore)
+		}
+		return account.Address, nil
+	}
+	s, err := c.NewDeployer()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return s.Address(), nil
+}
+
+// Keystore 打開配置的 keystore 目錄
+func (c *Config) Keystore() *signer.Keystore {
+	return signer.OpenKeystore(c.Signer.Keystore)
+}
+
+// SignerAccounts 返回配置的 keystore 或外部簽名者帳戶：signer.account 在前，之後是 signer.accounts
+func (c *Config) SignerAccounts() []common.Address {
+	var accounts []common.Address
+	if c.Signer.Account != "" {
+		accounts = append(accounts, common.HexToAddress(c.Signer.Account))
+	}
+	for _, account := range c.Signer.Accounts {
+		accounts = append(accounts, common.HexToAddress(account))
+	}
+	return accounts
+}
+
+// HDWallet 打開 signer.mnemonicFile 中的助記詞錢包
+func (c *Config) HDWallet() (*signer.HDWallet, error) {
+	if c.Signer.MnemonicFile == "" {
+		return nil, fmt.Errorf("signer.mnemonicFile: required (set SIGNER_MNEMONIC_FILE or --mnemonic.file)")
+	}
+	return signer.OpenHDWallet(c.Signer.MnemonicFile, c.Signer.MnemonicPassword)
+}
+
+// Passphrase 返回配置的 keystore 密碼來源
+func (c *Config) Passphrase() signer.PassphraseSource {
+	return signer.PassphraseSource{
+		File:  c.Signer.PasswordFile,
+		Value: c.Signer.Password,
+	}
+}
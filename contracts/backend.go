@@ -0,0 +1,34 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Backend 合約交互和部署所需的鏈上接口
+// *ethclient.Client 直接滿足此接口，模擬鏈、故障轉移客戶端或記錄代理只需實現相同的方法即可替換
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+
+	// ChainID 返回用於交易簽名的鏈 ID
+	ChainID(ctx context.Context) (*big.Int, error)
+	// BalanceAt 返回帳戶在指定區塊的餘額，blockNumber 為 nil 表示最新區塊
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	// NonceAt 返回帳戶在指定區塊已打包的交易數，blockNumber 為 nil 表示最新區塊
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	// HeaderByHash 返回指定哈希的區塊頭
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	// BlockNumber 返回最新區塊高度
+	BlockNumber(ctx context.Context) (uint64, error)
+	// TransactionByHash 返回交易以及它是否仍在等待打包
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// 確保 *ethclient.Client 滿足 Backend
+var _ Backend = (*ethclient.Client)(nil)
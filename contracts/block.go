@@ -0,0 +1,167 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrBlockNotFound 節點上沒有指定的區塊，或區塊不在主鏈上
+	ErrBlockNotFound = errors.New("block not found")
+	// ErrStateUnavailable 節點已經裁剪了指定區塊的狀態，需要連接歸檔節點
+	ErrStateUnavailable = errors.New("state at the requested block is not available on this node, an archive node is required")
+)
+
+// blockTags 區塊標籤對應的 rpc 區塊號
+var blockTags = map[string]rpc.BlockNumber{
+	"latest":    rpc.LatestBlockNumber,
+	"pending":   rpc.PendingBlockNumber,
+	"safe":      rpc.SafeBlockNumber,
+	"finalized": rpc.FinalizedBlockNumber,
+	"earliest":  rpc.EarliestBlockNumber,
+}
+
+// BlockRef 讀取合約狀態的區塊：區塊哈希、區塊號或標籤，零值表示最新區塊
+type BlockRef struct {
+	// Hash 不為 nil 時按哈希讀取，忽略 Number
+	Hash *common.Hash
+	// Number 區塊號，nil 表示 latest，標籤以 rpc 包中對應的負數表示
+	Number *big.Int
+}
+
+// ParseBlockRef 解析區塊號 (十進制或 0x 開頭的十六進制)、區塊哈希或 latest、pending、safe、finalized、earliest
+func ParseBlockRef(s string) (BlockRef, error) {
+	s = strings.TrimSpace(s)
+	if tag, ok := blockTags[strings.ToLower(s)]; ok {
+		return BlockRef{Number: big.NewInt(int64(tag))}, nil
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		if len(s) == 2+2*common.HashLength {
+			hash := common.HexToHash(s)
+			return BlockRef{Hash: &hash}, nil
+		}
+		if number, ok := new(big.Int).SetString(s[2:], 16); ok && number.IsUint64() {
+			return BlockRef{Number: number}, nil
+		}
+	} else if number, ok := new(big.Int).SetString(s, 10); ok && number.Sign() >= 0 && number.IsUint64() {
+		return BlockRef{Number: number}, nil
+	}
+	return BlockRef{}, fmt.Errorf("invalid block %q, expected a number, a block hash or latest, pending, safe, finalized, earliest", s)
+}
+
+// Pending 返回是否讀取 pending 區塊
+func (r BlockRef) Pending() bool {
+	return r.Hash == nil && r.Number != nil && r.Number.Int64() == int64(rpc.PendingBlockNumber)
+}
+
+func (r BlockRef) String() string {
+	switch {
+	case r.Hash != nil:
+		return r.Hash.Hex()
+	case r.Number == nil:
+		return rpc.LatestBlockNumber.String()
+	case r.Number.Sign() < 0:
+		return rpc.BlockNumber(r.Number.Int64()).String()
+	default:
+		return r.Number.String()
+	}
+}
+
+// ValueAt 在某個區塊讀取到的值
+type ValueAt struct {
+	Value       *big.Int
+	BlockNumber uint64
+	// BlockHash 讀取 pending 區塊時為空
+	BlockHash common.Hash
+}
+
+// GetValueAt 讀取指定區塊上存儲的值，以及實際讀取的區塊號和哈希
+// 標籤先解析為具體區塊再按區塊號讀取，使返回的值和區塊一致
+func (ci *ContractInteractor) GetValueAt(ctx context.Context, ref BlockRef) (*ValueAt, error) {
+	header, err := ci.blockHeader(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	result := &ValueAt{BlockNumber: header.Number.Uint64()}
+	if ref.Pending() {
+		opts.Pending = true
+	} else {
+		opts.BlockNumber = header.Number
+		result.BlockHash = header.Hash()
+	}
+
+	value, err := ci.contract.Get(opts)
+	if err != nil {
+		if errors.Is(err, bind.ErrNoCode) {
+			// 合約在該區塊之後才部署
+			return nil, fmt.Errorf("%w %s at block %d", ErrNoCode, ci.address.Hex(), result.BlockNumber)
+		}
+		if stateUnavailable(err) {
+			return nil, fmt.Errorf("%w (block %d): %v", ErrStateUnavailable, result.BlockNumber, err)
+		}
+		return nil, fmt.Errorf("failed to get value at block %s: %w", ref, classify(err))
+	}
+	result.Value = value
+	return result, nil
+}
+
+// blockHeader 返回區塊的頭，按哈希查詢時確認區塊仍在主鏈上
+func (ci *ContractInteractor) blockHeader(ctx context.Context, ref BlockRef) (*types.Header, error) {
+	if ref.Hash == nil {
+		header, err := ci.client.HeaderByNumber(ctx, ref.Number)
+		if errors.Is(err, ethereum.NotFound) || (err == nil && header == nil) {
+			return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, ref)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %s: %w", ref, classify(err))
+		}
+		return header, nil
+	}
+
+	header, err := ci.client.HeaderByHash(ctx, *ref.Hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %s: %w", ref, classify(err))
+	}
+	// 按區塊號讀取狀態，先確認該區塊號上的區塊就是請求的區塊
+	canonical, err := ci.client.HeaderByNumber(ctx, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", header.Number, classify(err))
+	}
+	if canonical.Hash() != *ref.Hash {
+		return nil, fmt.Errorf("%w: %s is not on the canonical chain", ErrBlockNotFound, ref)
+	}
+	return header, nil
+}
+
+// stateUnavailable 判斷錯誤是否因為節點沒有歷史狀態，各節點實現的錯誤信息不同
+func stateUnavailable(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range []string{
+		"missing trie node",
+		"historical state",
+		"state is not available",
+		"state not available",
+		"state histories",
+		"archive",
+		"pruned",
+	} {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseBlockRef(t *testing.T) {
+	hash := "0x" + strings.Repeat("ab", common.HashLength)
+	tests := []struct {
+		in      string
+		want    string
+		pending bool
+		wantErr bool
+	}{
+		{in: "latest", want: "latest"},
+		{in: " Latest ", want: "latest"},
+		{in: "pending", want: "pending", pending: true},
+		{in: "finalized", want: "finalized"},
+		{in: "42", want: "42"},
+		{in: "0x2a", want: "42"},
+		{in: "0X2A", want: "42"},
+		{in: hash, want: hash},
+		{in: "", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "0x", wantErr: true},
+		{in: "0xzz", wantErr: true},
+		{in: "18446744073709551616", wantErr: true},
+		{in: "0x" + strings.Repeat("ab", common.HashLength-1), wantErr: true},
+	}
+	for _, tt := range tests {
+		ref, err := ParseBlockRef(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseBlockRef(%q) = %s, want an error", tt.in, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBlockRef(%q): %v", tt.in, err)
+			continue
+		}
+		if ref.String() != tt.want || ref.Pending() != tt.pending {
+			t.Errorf("ParseBlockRef(%q) = %s (pending %v), want %s (pending %v)", tt.in, ref, ref.Pending(), tt.want, tt.pending)
+		}
+	}
+}
+
+func TestGetValueAt(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	address := chain.deploy(t)
+	ci := chain.interactor(t, address, 1)
+	ctx := context.Background()
+
+	// 區塊 2 部署合約，區塊 3 寫入 5，區塊 4 寫入 9
+	set := func(value int64) {
+		t.Helper()
+		if _, err := ci.SendSetValue(ctx, big.NewInt(value)); err != nil {
+			t.Fatalf("SendSetValue: %v", err)
+		}
+		chain.backend.Commit()
+	}
+	set(5)
+	block3, err := chain.client.HeaderByNumber(ctx, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set(9)
+
+	tests := []struct {
+		block string
+		value int64
+		at    uint64
+		err   error
+	}{
+		{block: "latest", value: 9, at: 4},
+		// pending 區塊建立在最新區塊之上，還沒有哈希
+		{block: "pending", value: 9, at: 5},
+		{block: "3", value: 5, at: 3},
+		{block: "0x3", value: 5, at: 3},
+		{block: block3.Hash().Hex(), value: 5, at: 3},
+		{block: "1", err: ErrNoCode},
+		{block: "100", err: ErrBlockNotFound},
+		{block: "0x" + strings.Repeat("ab", common.HashLength), err: ErrBlockNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.block, func(t *testing.T) {
+			ref, err := ParseBlockRef(tt.block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := ci.GetValueAt(ctx, ref)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetValueAt: %v", err)
+			}
+			if result.Value.Int64() != tt.value || result.BlockNumber != tt.at {
+				t.Fatalf("value %s at block %d, want %d at block %d", result.Value, result.BlockNumber, tt.value, tt.at)
+			}
+			if wantHash := !ref.Pending(); (result.BlockHash != common.Hash{}) != wantHash {
+				t.Fatalf("block hash = %s, want one only for mined blocks", result.BlockHash.Hex())
+			}
+		})
+	}
+
+	// 非歸檔節點只保留最近區塊的狀態
+	pruned, err := NewContractInteractor(prunedBackend{Backend: chain.client, keep: 4}, address.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pruned.GetValueAt(ctx, BlockRef{Number: big.NewInt(3)}); !errors.Is(err, ErrStateUnavailable) {
+		t.Fatalf("pruned state: err = %v, want ErrStateUnavailable", err)
+	}
+	if _, err := pruned.GetValueAt(ctx, BlockRef{}); err != nil {
+		t.Fatalf("latest state on a pruned node: %v", err)
+	}
+}
+
+// prunedBackend 模擬已裁剪歷史狀態的節點，讀取 keep 之前的區塊時返回 geth 的錯誤
+type prunedBackend struct {
+	Backend
+	keep int64
+}
+
+func (b prunedBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber != nil && blockNumber.Sign() >= 0 && blockNumber.Int64() < b.keep {
+		return nil, errors.New("missing trie node 7d4a6b5c3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c (path ) state 0x7d4a6b5c3f2e1d0c9b8a7f6e5d4c3b2a1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c is not available, not found")
+	}
+	return b.Backend.CallContract(ctx, call, blockNumber)
+}
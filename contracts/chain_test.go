@@ -0,0 +1,117 @@
+package contracts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testChain 測試用的模擬鏈，只在調用 Commit 時出塊，可以觀察等待打包的交易
+type testChain struct {
+	backend *simulated.Backend
+	client  simulated.Client
+	keys    []*ecdsa.PrivateKey
+}
+
+// newTestChain 創建有 accounts 個帳戶、每個 100 ETH 的模擬鏈，alloc 中的帳戶一併寫入創世區塊
+func newTestChain(t *testing.T, accounts int, alloc types.GenesisAlloc) *testChain {
+	t.Helper()
+	if alloc == nil {
+		alloc = make(types.GenesisAlloc)
+	}
+	chain := &testChain{}
+	for i := 0; i < accounts; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain.keys = append(chain.keys, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{
+			Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether)),
+		}
+	}
+
+	chain.backend = simulated.NewBackend(alloc)
+	t.Cleanup(func() { chain.backend.Close() })
+	// 創世區塊不是 post-merge 區塊，先出一個空塊，合約字節碼使用了 PUSH0
+	chain.backend.Commit()
+	chain.client = chain.backend.Client()
+	return chain
+}
+
+func (c *testChain) address(i int) common.Address {
+	return crypto.PubkeyToAddress(c.keys[i].PublicKey)
+}
+
+// deploy 以第一個帳戶部署 SimpleStorage 並出塊
+func (c *testChain) deploy(t *testing.T) common.Address {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(c.keys[0], c.chainID(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := DeployContracts(auth, c.client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.backend.Commit()
+	return address
+}
+
+// interactor 創建以 keys[i] 為發送帳戶的交互器，不要與 deploy 使用的第一個帳戶重複
+func (c *testChain) interactor(t *testing.T, address common.Address, senders ...int) *ContractInteractor {
+	t.Helper()
+	var signers []signer.Signer
+	for _, i := range senders {
+		signers = append(signers, signer.NewKeySigner(c.keys[i]))
+	}
+	ci, err := NewContractInteractor(c.client, address.Hex(), signers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ci
+}
+
+func (c *testChain) chainID(t *testing.T) *big.Int {
+	t.Helper()
+	chainID, err := c.client.ChainID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chainID
+}
+
+// autoCommit 在測試結束前定期出塊，用於會等待交易被打包的調用
+func (c *testChain) autoCommit(t *testing.T) {
+	t.Helper()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.backend.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+}
@@ -2,168 +2,192 @@ package contracts
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
-	"os"
 
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-// EstimateDeployment 估算部署合約的成本但不實際部署
-func EstimateDeployment(client *ethclient.Client, privateKeyHex string) (*Contracts, error) {
-	// 轉換私鑰
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert private key: %v", err)
-	}
+// DeployOptions 部署合約的選項
+type DeployOptions struct {
+	// FeeStrategy 交易費用策略
+	FeeStrategy FeeStrategy
+	// GasLimitMargin gas limit 相對 EstimateGas 結果的倍數，例如 1.2 表示多留 20% 餘量
+	GasLimitMargin float64
+}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
+// DefaultDeployOptions 返回預設的部署選項：預設費用策略，gas limit 多留 20%
+func DefaultDeployOptions() DeployOptions {
+	return DeployOptions{
+		FeeStrategy:    DefaultFeeStrategy(),
+		GasLimitMargin: 1.2,
 	}
+}
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
-	}
+// DeploymentEstimate 部署合約的預估結果，金額單位皆為 wei
+type DeploymentEstimate struct {
+	From        common.Address `json:"from"`
+	Nonce       uint64         `json:"nonce"`
+	GasEstimate uint64         `json:"gasEstimate"`
+	GasLimit    uint64         `json:"gasLimit"`
+	Fees        *Fees          `json:"fees"`
+	// MinCost 按當前 base fee + 小費 (legacy 為 gas price) 計算的預期成本
+	MinCost *big.Int `json:"minCost"`
+	// MaxCost 按 gas limit 和最高費用計算的最大成本
+	MaxCost   *big.Int `json:"maxCost"`
+	Balance   *big.Int `json:"balance"`
+	Shortfall *big.Int `json:"shortfall"`
+}
+
+// Sufficient 錢包餘額是否足以支付最大部署成本
+func (e *DeploymentEstimate) Sufficient() bool {
+	return e.Shortfall.Sign() == 0
+}
+
+// WeiToEth 將 wei 轉換為 ETH
+func WeiToEth(wei *big.Int) *big.Float {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.Ether))
+}
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+// EstimateDeployment 估算部署合約的成本但不實際部署
+// 對合約的創建 calldata 執行 EstimateGas，鏈上支持 EIP-1559 時按動態費用估算，否則使用 legacy gas price
+func EstimateDeployment(ctx context.Context, client Backend, from common.Address, opts DeployOptions) (*DeploymentEstimate, error) {
+	nonce, err := client.PendingNonceAt(ctx, from)
 	if err != nil {
-		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		return nil, fmt.Errorf("failed to get nonce: %w", classify(err))
 	}
 
-	chainID, err := client.ChainID(context.Background())
+	fees, err := opts.FeeStrategy.SuggestFees(ctx, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get chain id: %v", err)
+		return nil, err
 	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	// 對實際的創建 calldata 估算 gas
+	gasEstimate, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From: from,
+		Data: common.FromHex(ContractsBin),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %v", err)
+		return nil, fmt.Errorf("failed to estimate gas: %w", classify(err))
 	}
 
-	// 使用預設的 gas 限制
-	gasEstimate := uint64(300000) // 標準合約部署大約需要 200,000-250,000 gas
-	fmt.Printf("Using standard gas estimate: %d\n", gasEstimate)
-	auth.GasLimit = gasEstimate
-
-	// 計算預估的部署成本
-	gasCost := new(big.Float).Mul(
-		new(big.Float).SetInt(gasPrice),
-		new(big.Float).SetUint64(auth.GasLimit),
-	)
-	ethCost := new(big.Float).Quo(gasCost, new(big.Float).SetUint64(1e18))
-	fmt.Printf("Estimated deployment cost: %f ETH\n", ethCost)
+	gasLimit := withMargin(gasEstimate, opts.GasLimitMargin)
 
 	// 檢查錢包餘額
-	balance, err := client.BalanceAt(context.Background(), fromAddress, nil)
+	balance, err := client.BalanceAt(ctx, from, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %v", err)
+		return nil, fmt.Errorf("failed to get balance: %w", classify(err))
 	}
-	balanceEth := new(big.Float).Quo(new(big.Float).SetInt(balance), new(big.Float).SetUint64(1e18))
-	fmt.Printf("Wallet balance: %f ETH\n", balanceEth)
 
-	// 檢查餘額是否足夠
-	if balance.Cmp(new(big.Int).Mul(gasPrice, big.NewInt(int64(auth.GasLimit)))) < 0 {
-		return nil, fmt.Errorf("insufficient funds for deployment")
+	// 預期成本按 gas 估算值計算，最大成本按 gas limit 和最高費用計算
+	expectedPrice := fees.GasPrice
+	if fees.Dynamic() {
+		expectedPrice = new(big.Int).Add(fees.BaseFee, fees.GasTipCap)
 	}
+	minCost := new(big.Int).Mul(expectedPrice, new(big.Int).SetUint64(gasEstimate))
+	maxCost := new(big.Int).Mul(fees.MaxPricePerGas(), new(big.Int).SetUint64(gasLimit))
 
-	fmt.Println("=== 部署預覽 ===")
-	fmt.Printf("From address: %s\n", fromAddress.Hex())
-	fmt.Printf("Gas price: %s Wei\n", gasPrice.String())
-	fmt.Printf("Gas limit: %d\n", auth.GasLimit)
-	fmt.Printf("Nonce: %d\n", nonce)
+	shortfall := new(big.Int).Sub(maxCost, balance)
+	if shortfall.Sign() < 0 {
+		shortfall.SetInt64(0)
+	}
 
-	return nil, nil
+	return &DeploymentEstimate{
+		From:        from,
+		Nonce:       nonce,
+		GasEstimate: gasEstimate,
+		GasLimit:    gasLimit,
+		Fees:        fees,
+		MinCost:     minCost,
+		MaxCost:     maxCost,
+		Balance:     balance,
+		Shortfall:   shortfall,
+	}, nil
 }
 
-func DeployContract(client *ethclient.Client, privateKeyHex string) (*Contracts, error) {
-	// 轉換私鑰
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+// Deployment 部署交易及其結果
+type Deployment struct {
+	Address  common.Address
+	Tx       *types.Transaction
+	Receipt  *types.Receipt
+	Contract *Contracts
+	Estimate *DeploymentEstimate
+}
+
+// DeployContract 部署合約，支持 EIP-1559 時發送 type 2 交易
+// gas limit 由 EstimateGas 結果乘以 opts.GasLimitMargin 得出
+// 發送後等待交易被打包，確認交易成功且地址上的代碼與 ContractsBin 一致
+// 交易已發送但等待或校驗失敗時，返回的 Deployment 不為 nil，可用於查詢交易
+func DeployContract(ctx context.Context, client Backend, txSigner signer.Signer, opts DeployOptions) (*Deployment, error) {
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert private key: %v", err)
+		return nil, fmt.Errorf("failed to get chain id: %w", classify(err))
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("failed to get public key")
-	}
+	auth := signer.TransactOpts(ctx, txSigner, chainID)
 
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
+	estimate, err := EstimateDeployment(ctx, client, txSigner.Address(), opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %v", err)
+		return nil, err
 	}
+	log.Printf("Gas estimate: %d, gas limit: %d", estimate.GasEstimate, estimate.GasLimit)
+	log.Printf("Estimated deployment cost: %f - %f ETH", WeiToEth(estimate.MinCost), WeiToEth(estimate.MaxCost))
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+	// 檢查餘額是否足夠
+	if !estimate.Sufficient() {
+		return nil, fmt.Errorf("%w: deployment is short of %f ETH", ErrInsufficientFunds, WeiToEth(estimate.Shortfall))
 	}
 
-	chainID, err := client.ChainID(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to get chain id: %v", err)
-	}
+	auth.Nonce = new(big.Int).SetUint64(estimate.Nonce)
+	auth.Value = big.NewInt(0) // 不發送 ETH
+	auth.GasLimit = estimate.GasLimit
+	estimate.Fees.Apply(auth)
 
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
+	// 部署合約
+	address, tx, instance, err := DeployContracts(auth, client)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %v", err)
+		return nil, fmt.Errorf("failed to deploy contract: %w", classify(err))
 	}
 
-	// 使用預設的 gas 限制
-	gasEstimate := uint64(300000) // 標準合約部署大約需要 200,000-250,000 gas
-	fmt.Printf("Using standard gas estimate: %d\n", gasEstimate)
-	auth.GasLimit = gasEstimate
+	log.Printf("Deployment transaction sent: %s, waiting for it to be mined...", tx.Hash().Hex())
 
-	// 計算預估的部署成本
-	gasCost := new(big.Float).Mul(
-		new(big.Float).SetInt(gasPrice),
-		new(big.Float).SetUint64(auth.GasLimit),
-	)
-	ethCost := new(big.Float).Quo(gasCost, new(big.Float).SetUint64(1e18))
-	fmt.Printf("Estimated deployment cost: %f ETH\n", ethCost)
+	deployment := &Deployment{
+		Address:  address,
+		Tx:       tx,
+		Contract: instance,
+		Estimate: estimate,
+	}
 
-	// 檢查錢包餘額
-	balance, err := client.BalanceAt(context.Background(), fromAddress, nil)
+	// 等待部署交易被打包
+	receipt, err := bind.WaitMined(ctx, client, tx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %v", err)
+		return deployment, fmt.Errorf("failed to wait for deployment transaction: %w", classify(err))
 	}
-	balanceEth := new(big.Float).Quo(new(big.Float).SetInt(balance), new(big.Float).SetUint64(1e18))
-	fmt.Printf("Wallet balance: %f ETH\n", balanceEth)
-
-	// 檢查餘額是否足夠
-	if balance.Cmp(new(big.Int).Mul(gasPrice, big.NewInt(int64(auth.GasLimit)))) < 0 {
-		return nil, fmt.Errorf("insufficient funds for deployment")
+	deployment.Receipt = receipt
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return deployment, fmt.Errorf("deployment transaction %s failed in block %d", tx.Hash().Hex(), receipt.BlockNumber)
 	}
 
-	auth.Nonce = big.NewInt(int64(nonce))
-	auth.Value = big.NewInt(0) // 不發送 ETH
-	auth.GasPrice = gasPrice
-
-	// 部署合約
-	address, tx, instance, err := DeployContracts(auth, client)
-	if err != nil {
-		return nil, fmt.Errorf("failed to deploy contract: %v", err)
+	// 確認地址上的代碼就是 SimpleStorage
+	if err := VerifyCode(ctx, client, address); err != nil {
+		return deployment, err
 	}
 
-	// 打印部署信息
-	fmt.Println("\n=== 部署成功 ===")
-	fmt.Printf("合約地址: %s\n", address.Hex())
-	fmt.Printf("交易哈希: %s\n", tx.Hash().Hex())
+	log.Printf("Contract deployed at %s in block %d", address.Hex(), receipt.BlockNumber)
+	return deployment, nil
+}
 
-	// 保存合約地址到文件
-	addressFile := "contract_address.txt"
-	err = os.WriteFile(addressFile, []byte(address.Hex()), 0644)
-	if err != nil {
-		log.Printf("Warning: Failed to save contract address: %v", err)
+// withMargin gas 估算值乘以餘量倍數，倍數小於 1 時按 1 計算
+func withMargin(gasEstimate uint64, margin float64) uint64 {
+	if margin < 1 {
+		margin = 1
 	}
-
-	return instance, nil
+	return uint64(float64(gasEstimate) * margin)
 }
@@ -0,0 +1,99 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestEstimateDeployment(t *testing.T) {
+	chain := newTestChain(t, 1, nil)
+	ctx := context.Background()
+
+	estimate, err := EstimateDeployment(ctx, chain.client, chain.address(0), DefaultDeployOptions())
+	if err != nil {
+		t.Fatalf("EstimateDeployment: %v", err)
+	}
+	if estimate.GasEstimate == 0 || estimate.GasLimit != uint64(float64(estimate.GasEstimate)*1.2) {
+		t.Fatalf("gas estimate %d, limit %d, want limit = estimate × 1.2", estimate.GasEstimate, estimate.GasLimit)
+	}
+	if !estimate.Fees.Dynamic() || estimate.MinCost.Cmp(estimate.MaxCost) > 0 {
+		t.Fatalf("fees %s, min cost %s, max cost %s", estimate.Fees, estimate.MinCost, estimate.MaxCost)
+	}
+	if !estimate.Sufficient() || estimate.Nonce != 0 {
+		t.Fatalf("estimate = %+v, want sufficient with nonce 0", estimate)
+	}
+
+	// 沒有餘額的帳戶
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err = EstimateDeployment(ctx, chain.client, crypto.PubkeyToAddress(key.PublicKey), DefaultDeployOptions())
+	if err != nil {
+		t.Fatalf("EstimateDeployment: %v", err)
+	}
+	if estimate.Sufficient() || estimate.Shortfall.Cmp(estimate.MaxCost) != 0 {
+		t.Fatalf("shortfall = %s, want the whole max cost %s", estimate.Shortfall, estimate.MaxCost)
+	}
+	if _, err := DeployContract(ctx, chain.client, signer.NewKeySigner(key), DefaultDeployOptions()); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("DeployContract from an empty account: err = %v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestRPCUnavailableClassified(t *testing.T) {
+	// 已經關閉的節點
+	server := httptest.NewServer(nil)
+	server.Close()
+	client, err := ethclient.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := EstimateDeployment(ctx, client, crypto.PubkeyToAddress(key.PublicKey), DefaultDeployOptions()); !errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("EstimateDeployment: err = %v, want ErrRPCUnavailable", err)
+	}
+	if _, err := DeployContract(ctx, client, signer.NewKeySigner(key), DefaultDeployOptions()); !errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("DeployContract: err = %v, want ErrRPCUnavailable", err)
+	}
+
+	ci, err := NewContractInteractor(client, "0x00000000000000000000000000000000000000aa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ci.WatchEvents(ctx, nil, func(*ContractsDataStored) {}); !errors.Is(err, ErrRPCUnavailable) {
+		t.Fatalf("WatchEvents: err = %v, want ErrRPCUnavailable", err)
+	}
+}
+
+func TestDeployContract(t *testing.T) {
+	chain := newTestChain(t, 1, nil)
+	chain.autoCommit(t)
+	ctx := context.Background()
+
+	deployment, err := DeployContract(ctx, chain.client, signer.NewKeySigner(chain.keys[0]), DefaultDeployOptions())
+	if err != nil {
+		t.Fatalf("DeployContract: %v", err)
+	}
+	if deployment.Receipt == nil || deployment.Receipt.ContractAddress != deployment.Address {
+		t.Fatalf("receipt = %+v, want contract address %s", deployment.Receipt, deployment.Address.Hex())
+	}
+	if deployment.Tx.Gas() != deployment.Estimate.GasLimit || deployment.Receipt.GasUsed > deployment.Tx.Gas() {
+		t.Fatalf("gas limit %d, gas used %d, estimate %+v", deployment.Tx.Gas(), deployment.Receipt.GasUsed, deployment.Estimate)
+	}
+	if err := VerifyCode(ctx, chain.client, deployment.Address); err != nil {
+		t.Fatalf("VerifyCode: %v", err)
+	}
+}
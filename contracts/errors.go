@@ -0,0 +1,109 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 穩定的錯誤碼，API 在錯誤響應中返回，客戶端應以它們而不是錯誤信息區分錯誤
+const (
+	CodeInsufficientFunds      = "insufficient_funds"
+	CodeNonceTooLow            = "nonce_too_low"
+	CodeReplacementUnderpriced = "replacement_underpriced"
+	CodeExecutionReverted      = "execution_reverted"
+	CodeRPCUnavailable         = "rpc_unavailable"
+	CodeTimeout                = "timeout"
+	CodeWrongChain             = "wrong_chain"
+)
+
+var (
+	// ErrInsufficientFunds 發送帳戶的餘額不足以支付 gas 和轉帳金額
+	ErrInsufficientFunds = errors.New("insufficient funds for gas and value")
+	// ErrNonceTooLow 交易的 nonce 已被使用，通常是其他程序用同一個帳戶發送了交易
+	ErrNonceTooLow = errors.New("nonce too low")
+	// ErrReplacementUnderpriced 同一 nonce 上已有交易，新交易的費用不足以替換它
+	ErrReplacementUnderpriced = errors.New("replacement transaction underpriced")
+	// ErrRPCUnavailable 無法連接 RPC 節點，或節點返回了 HTTP 錯誤
+	ErrRPCUnavailable = errors.New("RPC endpoint unavailable")
+	// ErrTimeout 節點或交易確認沒有在超時前返回
+	ErrTimeout = errors.New("timed out")
+	// ErrWrongChain 節點所在的鏈與交易簽名使用的鏈 ID 不一致
+	ErrWrongChain = errors.New("wrong chain")
+)
+
+// errorCodes 錯誤和錯誤碼的對應
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrInsufficientFunds, CodeInsufficientFunds},
+	{ErrNonceTooLow, CodeNonceTooLow},
+	{ErrReplacementUnderpriced, CodeReplacementUnderpriced},
+	{ErrReverted, CodeExecutionReverted},
+	{ErrRPCUnavailable, CodeRPCUnavailable},
+	{ErrTimeout, CodeTimeout},
+	{ErrWrongChain, CodeWrongChain},
+}
+
+// ErrorCode 返回錯誤的錯誤碼，不屬於上述錯誤時返回空字符串
+func ErrorCode(err error) string {
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+	return ""
+}
+
+// classify 把節點返回的錯誤歸類為上述錯誤，返回的錯誤同時包裝歸類的錯誤和原錯誤
+// 無法歸類或已經歸類時原樣返回
+func classify(err error) error {
+	if err == nil || ErrorCode(err) != "" {
+		return err
+	}
+	if kind := errorKind(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+// errorKind 識別錯誤的類型
+// 節點通過 JSON-RPC 返回的錯誤只有錯誤信息，按 go-ethereum 和常見節點的錯誤信息匹配
+func errorKind(err error) error {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "insufficient funds"):
+		return ErrInsufficientFunds
+	case strings.Contains(msg, "nonce too low"):
+		return ErrNonceTooLow
+	case strings.Contains(msg, "replacement transaction underpriced"):
+		return ErrReplacementUnderpriced
+	case strings.Contains(msg, "invalid chain id"):
+		return ErrWrongChain
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrTimeout
+	}
+
+	var (
+		httpErr rpc.HTTPError
+		opErr   *net.OpError
+		urlErr  *url.Error
+	)
+	if errors.As(err, &httpErr) || errors.As(err, &opErr) || errors.As(err, &urlErr) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, rpc.ErrClientQuit) {
+		return ErrRPCUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// feeSource 提供計算交易費用所需的鏈上數據
+type feeSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+}
+
+// FeeStrategy 交易費用策略
+// 鏈上有 base fee 時發送 EIP-1559 (type 2) 交易，否則自動退回 legacy gas price
+type FeeStrategy struct {
+	// BaseFeeMultiplier 計算 GasFeeCap 時 base fee 的倍數，用於應對接下來幾個區塊 base fee 上漲
+	BaseFeeMultiplier float64
+	// MaxFeeCap 每單位 gas 願意支付的最高費用 (wei)，nil 表示不限制；legacy 模式下限制 GasPrice
+	MaxFeeCap *big.Int
+	// MaxPriorityFee 每單位 gas 最高小費 (wei)，nil 表示不限制
+	MaxPriorityFee *big.Int
+}
+
+// DefaultFeeStrategy 返回預設的費用策略：GasFeeCap = 2 * baseFee + tip，不設上限
+func DefaultFeeStrategy() FeeStrategy {
+	return FeeStrategy{
+		BaseFeeMultiplier: 2,
+	}
+}
+
+// Fees 一筆交易的費用設定，GasPrice 和 GasTipCap/GasFeeCap 只會設置其中一組
+type Fees struct {
+	GasPrice  *big.Int `json:"gasPrice,omitempty"`
+	GasTipCap *big.Int `json:"gasTipCap,omitempty"`
+	GasFeeCap *big.Int `json:"gasFeeCap,omitempty"`
+	BaseFee   *big.Int `json:"baseFee,omitempty"`
+}
+
+// Dynamic 是否為 EIP-1559 動態費用
+func (f *Fees) Dynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// MaxPricePerGas 每單位 gas 最多可能支付的費用，用於檢查餘額
+func (f *Fees) MaxPricePerGas() *big.Int {
+	if f.Dynamic() {
+		return f.GasFeeCap
+	}
+	return f.GasPrice
+}
+
+// Apply 將費用設置到交易選項上
+func (f *Fees) Apply(opts *bind.TransactOpts) {
+	opts.GasPrice = f.GasPrice
+	opts.GasTipCap = f.GasTipCap
+	opts.GasFeeCap = f.GasFeeCap
+}
+
+// String 返回便於打印的費用描述
+func (f *Fees) String() string {
+	if f.Dynamic() {
+		return fmt.Sprintf("base fee %s Wei, max priority fee %s Wei, max fee %s Wei", f.BaseFee, f.GasTipCap, f.GasFeeCap)
+	}
+	return fmt.Sprintf("gas price %s Wei (legacy)", f.GasPrice)
+}
+
+// SuggestFees 根據最新區塊頭和節點建議計算交易費用
+func (s FeeStrategy) SuggestFees(ctx context.Context, client feeSource) (*Fees, error) {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest header: %w", classify(err))
+	}
+
+	// 沒有 base fee 的鏈 (London 之前) 使用 legacy 交易
+	if head.BaseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %w", classify(err))
+		}
+		if s.MaxFeeCap != nil && gasPrice.Cmp(s.MaxFeeCap) > 0 {
+			gasPrice = new(big.Int).Set(s.MaxFeeCap)
+		}
+		return &Fees{GasPrice: gasPrice}, nil
+	}
+
+	tip, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas tip cap: %w", classify(err))
+	}
+	if s.MaxPriorityFee != nil && tip.Cmp(s.MaxPriorityFee) > 0 {
+		tip = new(big.Int).Set(s.MaxPriorityFee)
+	}
+
+	// GasFeeCap = baseFee * multiplier + tip
+	multiplier := s.BaseFeeMultiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	scaledBaseFee, _ := new(big.Float).Mul(
+		new(big.Float).SetInt(head.BaseFee),
+		big.NewFloat(multiplier),
+	).Int(nil)
+	feeCap := new(big.Int).Add(scaledBaseFee, tip)
+
+	if s.MaxFeeCap != nil && feeCap.Cmp(s.MaxFeeCap) > 0 {
+		feeCap = new(big.Int).Set(s.MaxFeeCap)
+	}
+	// 小費不能超過 fee cap
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+
+	return &Fees{
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		BaseFee:   head.BaseFee,
+	}, nil
+}
@@ -2,26 +2,39 @@ package contracts
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"time"
+
+	"Abby/signer"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // ContractInteractor 用於與合約交互的結構體
 type ContractInteractor struct {
-	client   *ethclient.Client
+	client   Backend
 	contract *Contracts
-	auth     *bind.TransactOpts
+	chainID  *big.Int
 	address  common.Address
+	pool     *senderPool
+	tracker  *txTracker
+
+	feeStrategy    FeeStrategy
+	gasLimitMargin float64
 }
 
-// NewContractInteractor 創建新的合約交互器
-func NewContractInteractor(client *ethclient.Client, contractAddress string, privateKey string) (*ContractInteractor, error) {
+// ErrReadOnly 交互器沒有簽名者，不能發送交易
+var ErrReadOnly = errors.New("server is in read-only mode, no signer is available to send transactions")
+
+// NewContractInteractor 創建新的合約交互器，寫入交易分配給 signers 中的帳戶發送
+// 沒有 signers 時創建只能讀取的交互器
+func NewContractInteractor(client Backend, contractAddress string, signers ...signer.Signer) (*ContractInteractor, error) {
 	// 轉換合約地址
 	address := common.HexToAddress(contractAddress)
 
@@ -31,10 +44,23 @@ func NewContractInteractor(client *ethclient.Client, contractAddress string, pri
 		return nil, fmt.Errorf("failed to create contract instance: %v", err)
 	}
 
-	// 轉換私鑰
-	pk, err := crypto.HexToECDSA(privateKey)
+	pool, err := newSenderPool(client, signers)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert private key: %v", err)
+		return nil, err
+	}
+
+	ci := &ContractInteractor{
+		client:   client,
+		contract: contract,
+		address:  address,
+		pool:     pool,
+		tracker:  newTxTracker(),
+
+		feeStrategy:    DefaultFeeStrategy(),
+		gasLimitMargin: DefaultDeployOptions().GasLimitMargin,
+	}
+	if len(signers) == 0 {
+		return ci, nil
 	}
 
 	// 獲取鏈ID
@@ -43,93 +69,261 @@ func NewContractInteractor(client *ethclient.Client, contractAddress string, pri
 		return nil, fmt.Errorf("failed to get chain id: %v", err)
 	}
 
-	// 創建交易選項
-	auth, err := bind.NewKeyedTransactorWithChainID(pk, chainID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %v", err)
+	ci.chainID = chainID
+	return ci, nil
+}
+
+// ReadOnly 返回交互器是否沒有簽名者、只能讀取
+func (ci *ContractInteractor) ReadOnly() bool {
+	return len(ci.pool.senders) == 0
+}
+
+// SetFeeStrategy 設置之後發送交易時使用的費用策略，應在開始處理請求前調用
+func (ci *ContractInteractor) SetFeeStrategy(strategy FeeStrategy) {
+	ci.feeStrategy = strategy
+}
+
+// SetGasLimitMargin 設置寫入交易 gas limit 相對預估 gas 的倍數，應在開始處理請求前調用
+func (ci *ContractInteractor) SetGasLimitMargin(margin float64) {
+	ci.gasLimitMargin = margin
+}
+
+// BeforeSend 交易簽名之後、廣播之前調用，返回錯誤時不發送交易並把錯誤返回給調用者
+type BeforeSend func(tx *types.Transaction) error
+
+type beforeSendKey struct{}
+
+// WithBeforeSend 返回帶有 hook 的 ctx，SendSetValue、SpeedUp 和 Cancel 在廣播交易之前調用它
+// 用於在發送前按交易的最高費用預留 gas 預算等
+func WithBeforeSend(ctx context.Context, hook BeforeSend) context.Context {
+	return context.WithValue(ctx, beforeSendKey{}, hook)
+}
+
+// beforeSend 調用 ctx 中的 hook，沒有 hook 時返回 nil
+func beforeSend(ctx context.Context, tx *types.Transaction) error {
+	if hook, ok := ctx.Value(beforeSendKey{}).(BeforeSend); ok {
+		return hook(tx)
 	}
+	return nil
+}
 
-	return &ContractInteractor{
-		client:   client,
-		contract: contract,
-		auth:     auth,
-		address:  address,
-	}, nil
+// rejectedError BeforeSend 拒絕發送的交易，不計入帳戶的失敗次數
+type rejectedError struct {
+	err error
 }
 
+func (e *rejectedError) Error() string { return e.err.Error() }
+
+func (e *rejectedError) Unwrap() error { return e.err }
+
 // GetValue 讀取當前存儲的值
 func (ci *ContractInteractor) GetValue() (*big.Int, error) {
 	value, err := ci.contract.Get(&bind.CallOpts{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get value: %v", err)
+		return nil, fmt.Errorf("failed to get value: %w", classify(err))
 	}
 	return value, nil
 }
 
-// SetValue 設置新的值
-func (ci *ContractInteractor) SetValue(value *big.Int) error {
-	// 獲取 gas 價格
-	gasPrice, err := ci.client.SuggestGasPrice(context.Background())
+// SendSetValue 預執行並發送設置新值的交易，不等待交易被確認
+// 預執行回滾時不發送，返回的錯誤包含 *RevertError；ctx 取消時停止計算費用、預執行和簽名
+// 簽名之後、廣播之前調用 ctx 中的 BeforeSend，它返回的錯誤原樣返回
+func (ci *ContractInteractor) SendSetValue(ctx context.Context, value *big.Int) (*types.Transaction, error) {
+	if ci.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+
+	// 計算交易費用
+	fees, err := ci.feeStrategy.SuggestFees(ctx, ci.client)
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %v", err)
+		return nil, err
 	}
-	ci.auth.GasPrice = gasPrice
 
-	// 發送交易
-	tx, err := ci.contract.Set(ci.auth, value)
+	// 選擇發送帳戶，不同帳戶的交易使用各自的 nonce 序列，可以並行發送
+	sender, err := ci.pool.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer sender.release()
+
+	// 以該帳戶在 pending 區塊上預執行，回滾的交易不發送
+	data, err := ci.setCalldata(value)
+	if err != nil {
+		return nil, err
+	}
+	sim, err := ci.simulate(ctx, sender.address(), data)
+	if err != nil {
+		return nil, err
+	}
+	if !sim.Success {
+		log.Printf("Transaction from %s not sent: %v", sender.address().Hex(), sim.Revert)
+		return nil, fmt.Errorf("failed to set value: %w", sim.Revert)
+	}
+
+	// 在該帳戶 nonce 管理器的鎖內簽名和發送交易
+	tx, err := sender.nonces.Send(ctx, func(nonce uint64) (*types.Transaction, error) {
+		opts := ci.transactOpts(ctx, sender.signer, nonce)
+		fees.Apply(opts)
+		// 預執行時已估算 gas，不再重複估算，按配置的倍數留出餘量
+		opts.GasLimit = withMargin(sim.GasEstimate, ci.gasLimitMargin)
+		// 先只簽名，經過 BeforeSend 之後再廣播
+		opts.NoSend = true
+		tx, err := ci.contract.Set(opts, value)
+		if err != nil {
+			return nil, err
+		}
+		if err := beforeSend(ctx, tx); err != nil {
+			return nil, &rejectedError{err}
+		}
+		if err := ci.client.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx, nil
+	})
+	var rejected *rejectedError
+	if errors.As(err, &rejected) {
+		return nil, rejected.err
+	}
 	if err != nil {
-		return fmt.Errorf("failed to set value: %v", err)
+		sender.record(0, err)
+		return nil, fmt.Errorf("failed to set value: %w", classify(err))
 	}
+	sender.record(tx.Nonce(), nil)
+
+	// 記錄交易以便之後查詢狀態
+	ci.tracker.track(tx, sender.address())
 
 	// 打印交易哈希
-	log.Printf("Transaction sent: %s", tx.Hash().Hex())
-	log.Printf("Waiting for transaction to be mined...")
+	log.Printf("Transaction sent: %s (from %s, nonce %d)", tx.Hash().Hex(), sender.address().Hex(), tx.Nonce())
+	return tx, nil
+}
+
+// transactOpts 為每筆交易創建一份交易選項，避免並發請求互相修改
+func (ci *ContractInteractor) transactOpts(ctx context.Context, s signer.Signer, nonce uint64) *bind.TransactOpts {
+	opts := signer.TransactOpts(ctx, s, ci.chainID)
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	return opts
+}
+
+// SetValue 設置新的值並等待交易被確認
+func (ci *ContractInteractor) SetValue(ctx context.Context, value *big.Int) (*types.Receipt, error) {
+	tx, err := ci.SendSetValue(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	return ci.WaitMined(ctx, tx)
+}
 
-	// 等待交易被確認
-	receipt, err := bind.WaitMined(context.Background(), ci.client, tx)
+// WaitMined 等待交易被確認，交易在鏈上回滾時返回收據和包含 *RevertError 的錯誤
+func (ci *ContractInteractor) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	log.Printf("Waiting for transaction %s to be mined...", tx.Hash().Hex())
+
+	receipt, err := bind.WaitMined(ctx, ci.client, tx)
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %v", err)
+		return nil, fmt.Errorf("failed to wait for transaction: %w", classify(err))
+	}
+	// 回滾的交易同樣消耗了 nonce
+	if tracked, ok := ci.tracker.get(tx.Hash()); ok {
+		ci.mined(tracked.from, tx.Nonce())
 	}
 
-	if receipt.Status == 0 {
-		return fmt.Errorf("transaction failed")
+	if receipt.Status == types.ReceiptStatusFailed {
+		// 收據中沒有回滾原因，預執行通過的交易在打包時才回滾
+		return receipt, fmt.Errorf("transaction failed in block %d: %w", receipt.BlockNumber, &RevertError{Kind: RevertKindUnknown})
 	}
 
 	log.Printf("Transaction confirmed in block %d", receipt.BlockNumber)
-	return nil
+	return receipt, nil
 }
 
-// WatchEvents 監聽合約事件
-func (ci *ContractInteractor) WatchEvents() error {
-	// 創建事件過濾器
-	logs, err := ci.contract.FilterDataStored(&bind.FilterOpts{
-		Start: 0, // 從最新的區塊開始
-	})
+// watchPollInterval 節點不支持訂閱 (例如 HTTP RPC) 時輪詢新事件的間隔
+const watchPollInterval = 4 * time.Second
+
+// WatchEvents 監聽合約事件直到 ctx 被取消，fromBlock 不為 nil 時先處理該區塊之後的歷史事件
+// 節點不支持訂閱時改為輪詢
+func (ci *ContractInteractor) WatchEvents(ctx context.Context, fromBlock *uint64, handle func(event *ContractsDataStored)) error {
+	// 記下當前區塊，歷史事件和新事件以它為界
+	head, err := ci.client.BlockNumber(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to filter events: %v", err)
+		return fmt.Errorf("failed to get block number: %w", classify(err))
 	}
-	defer logs.Close()
 
-	// 打印歷史事件
-	for logs.Next() {
-		log.Printf("Historical event - New value stored: %s", logs.Event.NewValue)
+	// 處理歷史事件
+	if fromBlock != nil && *fromBlock <= head {
+		if err := ci.filterEvents(ctx, *fromBlock, head, handle); err != nil {
+			return err
+		}
 	}
 
 	// 創建事件訂閱
+	start := head + 1
 	sink := make(chan *ContractsDataStored)
-	sub, err := ci.contract.WatchDataStored(&bind.WatchOpts{}, sink)
+	sub, err := ci.contract.WatchDataStored(&bind.WatchOpts{Start: &start, Context: ctx}, sink)
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return ci.pollEvents(ctx, start, handle)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to watch events: %v", err)
+		return fmt.Errorf("failed to watch events: %w", classify(err))
 	}
 	defer sub.Unsubscribe()
 
 	// 監聽新事件
 	for {
 		select {
+		case <-ctx.Done():
+			return nil
 		case err := <-sub.Err():
-			return fmt.Errorf("event subscription error: %v", err)
+			return fmt.Errorf("event subscription error: %w", classify(err))
 		case event := <-sink:
-			log.Printf("New event - Value stored: %s", event.NewValue)
+			handle(event)
 		}
 	}
 }
+
+// pollEvents 定期查詢 next 之後新區塊中的事件
+func (ci *ContractInteractor) pollEvents(ctx context.Context, next uint64, handle func(event *ContractsDataStored)) error {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		head, err := ci.client.BlockNumber(ctx)
+		if err != nil {
+			log.Printf("Failed to get block number: %v", classify(err))
+			continue
+		}
+		if head < next {
+			continue
+		}
+		if err := ci.filterEvents(ctx, next, head, handle); err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		next = head + 1
+	}
+}
+
+// filterEvents 查詢 [from, to] 區間內的事件
+func (ci *ContractInteractor) filterEvents(ctx context.Context, from, to uint64, handle func(event *ContractsDataStored)) error {
+	logs, err := ci.contract.FilterDataStored(&bind.FilterOpts{
+		Start:   from,
+		End:     &to,
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter events: %w", classify(err))
+	}
+	defer logs.Close()
+
+	for logs.Next() {
+		handle(logs.Event)
+	}
+	if err := logs.Error(); err != nil {
+		return fmt.Errorf("failed to iterate events: %w", classify(err))
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSendSetValue(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	// 兩筆交易在同一個區塊之前發送，本地 nonce 依次遞增
+	first, err := ci.SendSetValue(ctx, big.NewInt(42))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	second, err := ci.SendSetValue(ctx, big.NewInt(43))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	if first.Nonce() != 0 || second.Nonce() != 1 {
+		t.Fatalf("nonces = %d, %d, want 0, 1", first.Nonce(), second.Nonce())
+	}
+
+	status, err := ci.TxStatus(ctx, first.Hash())
+	if err != nil {
+		t.Fatalf("TxStatus: %v", err)
+	}
+	if status.Status != TxStatusPending {
+		t.Fatalf("status before commit = %s, want %s", status.Status, TxStatusPending)
+	}
+	if stats := ci.SenderStats(); stats[0].Pending != 2 {
+		t.Fatalf("sender stats before commit = %+v, want 2 pending", stats[0])
+	}
+
+	chain.backend.Commit()
+	receipt, err := ci.WaitMined(ctx, second)
+	if err != nil {
+		t.Fatalf("WaitMined: %v", err)
+	}
+	if receipt.GasUsed > second.Gas() {
+		t.Fatalf("gas used %d exceeds gas limit %d", receipt.GasUsed, second.Gas())
+	}
+	// 沒有運行帳戶檢查時，收據也會更新等待打包的交易數
+	if stats := ci.SenderStats(); stats[0].Pending != 0 || stats[0].NextNonce != 2 {
+		t.Fatalf("sender stats after mining = %+v, want nothing pending", stats[0])
+	}
+
+	value, err := ci.GetValue()
+	if err != nil {
+		t.Fatalf("GetValue: %v", err)
+	}
+	if value.Int64() != 43 {
+		t.Fatalf("value = %s, want 43", value)
+	}
+}
+
+func TestSendSetValueGasLimitMargin(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	sim, err := ci.SimulateSetValue(ctx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("SimulateSetValue: %v", err)
+	}
+	// 未設置時使用與部署相同的預設倍數
+	tests := []struct {
+		set    float64
+		margin float64
+	}{
+		{0, DefaultDeployOptions().GasLimitMargin},
+		{1.5, 1.5},
+	}
+	for _, tt := range tests {
+		if tt.set != 0 {
+			ci.SetGasLimitMargin(tt.set)
+		}
+		tx, err := ci.SendSetValue(ctx, big.NewInt(1))
+		if err != nil {
+			t.Fatalf("SendSetValue: %v", err)
+		}
+		if want := uint64(float64(sim.GasEstimate) * tt.margin); tx.Gas() != want || want <= sim.GasEstimate {
+			t.Fatalf("margin %v: gas limit %d, want %d for estimate %d", tt.margin, tx.Gas(), want, sim.GasEstimate)
+		}
+	}
+}
+
+func TestSendSetValueReadOnly(t *testing.T) {
+	chain := newTestChain(t, 1, nil)
+	ci := chain.interactor(t, chain.deploy(t))
+
+	if _, err := ci.SendSetValue(context.Background(), big.NewInt(1)); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("err = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestSendSetValueBeforeSend(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	errRejected := errors.New("rejected")
+
+	// hook 拒絕時不廣播，nonce 不前進，也不計入帳戶的失敗次數
+	var rejected *types.Transaction
+	ctx := WithBeforeSend(context.Background(), func(tx *types.Transaction) error {
+		rejected = tx
+		return errRejected
+	})
+	if _, err := ci.SendSetValue(ctx, big.NewInt(1)); !errors.Is(err, errRejected) {
+		t.Fatalf("err = %v, want the hook's error", err)
+	}
+	if rejected == nil {
+		t.Fatal("hook was not called")
+	}
+	if _, _, err := chain.client.TransactionByHash(context.Background(), rejected.Hash()); err == nil {
+		t.Fatal("rejected transaction was broadcast")
+	}
+	if stats := ci.SenderStats(); stats[0].Failed != 0 || stats[0].Pending != 0 {
+		t.Fatalf("sender stats = %+v, want nothing failed or pending", stats[0])
+	}
+
+	var seen *types.Transaction
+	ctx = WithBeforeSend(context.Background(), func(tx *types.Transaction) error {
+		seen = tx
+		return nil
+	})
+	tx, err := ci.SendSetValue(ctx, big.NewInt(2))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	if tx.Nonce() != rejected.Nonce() || seen == nil || seen.Hash() != tx.Hash() {
+		t.Fatalf("sent nonce %d, want %d with the hook seeing the sent transaction", tx.Nonce(), rejected.Nonce())
+	}
+}
+
+func TestSendSetValueCanceled(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ci.SendSetValue(ctx, big.NewInt(1)); err == nil {
+		t.Fatal("SendSetValue with a canceled context succeeded")
+	}
+}
+
+func TestWaitMinedTimeout(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+
+	tx, err := ci.SendSetValue(context.Background(), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := ci.WaitMined(ctx, tx); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+}
@@ -0,0 +1,100 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nonceSource 提供帳戶在節點上的 pending nonce
+type nonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager 在本地按順序分配 nonce，避免並發交易使用相同的 nonce
+type NonceManager struct {
+	mu      sync.Mutex
+	source  nonceSource
+	address common.Address
+	next    uint64
+	synced  bool
+}
+
+// NewNonceManager 創建新的 nonce 管理器，第一次使用時才向節點同步
+func NewNonceManager(source nonceSource, address common.Address) *NonceManager {
+	return &NonceManager{
+		source:  source,
+		address: address,
+	}
+}
+
+// Send 在鎖內分配下一個 nonce 並調用 send 簽名和發送交易
+// 發送成功後 nonce 才會遞增；若節點回報 nonce 相關錯誤則在下次使用前重新同步
+func (m *NonceManager) Send(ctx context.Context, send func(nonce uint64) (*types.Transaction, error)) (*types.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.syncLocked(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := send(m.next)
+	if err != nil {
+		if isNonceError(err) {
+			log.Printf("Nonce %d rejected for %s, resyncing: %v", m.next, m.address.Hex(), err)
+			m.synced = false
+		}
+		return nil, err
+	}
+
+	m.next++
+	return tx, nil
+}
+
+// Reset 標記本地 nonce 已失效，下次發送前會重新向節點同步
+// 在發現交易被丟棄、出現 nonce 空洞時調用
+func (m *NonceManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+}
+
+// Next 返回下一個將被分配的 nonce
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.synced {
+		if err := m.syncLocked(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return m.next, nil
+}
+
+func (m *NonceManager) syncLocked(ctx context.Context) error {
+	nonce, err := m.source.PendingNonceAt(ctx, m.address)
+	if err != nil {
+		return fmt.Errorf("failed to get nonce: %w", classify(err))
+	}
+	m.next = nonce
+	m.synced = true
+	return nil
+}
+
+// isNonceError 判斷節點是否因為 nonce 衝突而拒絕交易
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") ||
+		strings.Contains(msg, "nonce too high") ||
+		strings.Contains(msg, "replacement transaction underpriced") ||
+		strings.Contains(msg, "invalid transaction nonce") ||
+		strings.Contains(msg, "already known")
+}
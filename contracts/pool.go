@@ -0,0 +1,338 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// 發送帳戶的分配策略
+const (
+	// AssignLeastPending 選擇等待打包交易最少的帳戶
+	AssignLeastPending = "least-pending"
+	// AssignRoundRobin 按順序輪流使用帳戶
+	AssignRoundRobin = "round-robin"
+)
+
+// ErrNoSender 所有發送帳戶都因餘額不足或交易卡住而暫停使用
+var ErrNoSender = errors.New("no sender account available")
+
+// PoolOptions 發送帳戶池的選項
+type PoolOptions struct {
+	// Strategy 分配策略，AssignLeastPending 或 AssignRoundRobin
+	Strategy string
+	// MinBalance 餘額低於此值的帳戶暫停使用，nil 或 0 表示不檢查
+	MinBalance *big.Int
+	// StuckAfter 最早一筆等待打包的交易超過此時間仍未打包時，暫停使用該帳戶
+	StuckAfter time.Duration
+	// CheckInterval 檢查帳戶餘額和等待中交易的間隔
+	CheckInterval time.Duration
+}
+
+// DefaultPoolOptions 返回預設的帳戶池選項：按等待交易數分配，餘額低於 0.001 ETH 或交易 5 分鐘未打包時暫停
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		Strategy:      AssignLeastPending,
+		MinBalance:    big.NewInt(params.Ether / 1000),
+		StuckAfter:    5 * time.Minute,
+		CheckInterval: 15 * time.Second,
+	}
+}
+
+// SenderStats 發送帳戶的狀態和統計
+type SenderStats struct {
+	Address string `json:"address" example:"0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"`
+	// Available 帳戶是否參與分配，否則 Reason 說明暫停的原因
+	Available bool   `json:"available" example:"true"`
+	Reason    string `json:"reason,omitempty" example:"balance 0.000500 ETH below minimum 0.001000 ETH"`
+	// Balance 最近一次檢查時的餘額 (wei)
+	Balance string `json:"balance,omitempty" example:"1000000000000000000"`
+	// Pending 已發送但尚未打包的交易數
+	Pending   uint64     `json:"pending" example:"2"`
+	NextNonce uint64     `json:"nextNonce" example:"12"`
+	Sent      uint64     `json:"sent" example:"12"`
+	Failed    uint64     `json:"failed" example:"1"`
+	LastSent  *time.Time `json:"lastSent,omitempty"`
+	LastCheck *time.Time `json:"lastCheck,omitempty"`
+}
+
+// sender 帳戶池中的一個發送帳戶，每個帳戶有獨立的 nonce 序列
+type sender struct {
+	signer signer.Signer
+	nonces *NonceManager
+
+	mu sync.Mutex
+	// confirmed 已知已打包的 nonce 數，由檢查和收據更新，pending = 本地下一個 nonce - confirmed
+	confirmed uint64
+	next      uint64
+	// inflight 已分配但還沒有拿到 nonce 的寫入數，並發寫入不會都選中同一個帳戶
+	inflight  uint64
+	balance   *big.Int
+	reason    string
+	sent      uint64
+	failed    uint64
+	lastSent  time.Time
+	lastCheck time.Time
+}
+
+func (s *sender) address() common.Address {
+	return s.signer.Address()
+}
+
+func (s *sender) pending() uint64 {
+	if s.next < s.confirmed {
+		return 0
+	}
+	return s.next - s.confirmed
+}
+
+// load 分配時使用的負載：等待打包的交易數加上正在準備的寫入數
+func (s *sender) load() uint64 {
+	return s.pending() + s.inflight
+}
+
+// release 結束 acquire 分配的寫入，應在 nonces.Send 返回後或放棄發送時調用
+func (s *sender) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight > 0 {
+		s.inflight--
+	}
+}
+
+// record 記錄一次發送的結果
+func (s *sender) record(nonce uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.failed++
+		return
+	}
+	s.sent++
+	s.lastSent = time.Now()
+	if nonce+1 > s.next {
+		s.next = nonce + 1
+	}
+}
+
+// mined 交易已被打包，之前的 nonce 也都已打包，不必等下一次檢查就更新等待數
+func (s *sender) mined(nonce uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nonce+1 > s.confirmed {
+		s.confirmed = nonce + 1
+	}
+}
+
+// senderPool 管理多個發送帳戶，把寫入分配給可用的帳戶
+type senderPool struct {
+	opts    PoolOptions
+	senders []*sender
+	byAddr  map[common.Address]*sender
+
+	mu     sync.Mutex
+	cursor int
+}
+
+func newSenderPool(client Backend, signers []signer.Signer) (*senderPool, error) {
+	p := &senderPool{
+		opts:   DefaultPoolOptions(),
+		byAddr: make(map[common.Address]*sender),
+	}
+	for _, s := range signers {
+		if _, ok := p.byAddr[s.Address()]; ok {
+			return nil, fmt.Errorf("duplicate sender account %s", s.Address().Hex())
+		}
+		snd := &sender{
+			signer: s,
+			nonces: NewNonceManager(client, s.Address()),
+		}
+		p.senders = append(p.senders, snd)
+		p.byAddr[s.Address()] = snd
+	}
+	return p, nil
+}
+
+// lookup 返回地址對應的發送帳戶，包括暫停使用的帳戶
+func (p *senderPool) lookup(address common.Address) *sender {
+	return p.byAddr[address]
+}
+
+// mined 記錄帳戶發送的交易已被打包，不是帳戶池中的帳戶時忽略
+func (p *senderPool) mined(address common.Address, nonce uint64) {
+	if s := p.lookup(address); s != nil {
+		s.mined(nonce)
+	}
+}
+
+// acquire 按分配策略選擇一個可用的帳戶，並把它計入正在準備的寫入，用完後調用 release
+func (p *senderPool) acquire() (*sender, error) {
+	return p.choose(true)
+}
+
+// peek 返回下一次 acquire 會選擇的帳戶，不影響之後的分配
+func (p *senderPool) peek() (*sender, error) {
+	return p.choose(false)
+}
+
+// choose 按分配策略選擇一個可用的帳戶，advance 為 true 時移動游標
+func (p *senderPool) choose(advance bool) (*sender, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *sender
+	var bestLoad uint64
+	for i := range p.senders {
+		// 從游標開始遍歷，輪詢時依次選擇，按等待數選擇時相同數量的帳戶也會輪流使用
+		s := p.senders[(p.cursor+i)%len(p.senders)]
+		s.mu.Lock()
+		available, load := s.reason == "", s.load()
+		s.mu.Unlock()
+		if !available {
+			continue
+		}
+		if p.opts.Strategy == AssignRoundRobin {
+			best = s
+			break
+		}
+		if best == nil || load < bestLoad {
+			best, bestLoad = s, load
+		}
+	}
+	if best == nil {
+		return nil, ErrNoSender
+	}
+	if !advance {
+		return best, nil
+	}
+	for i, s := range p.senders {
+		if s == best {
+			p.cursor = i + 1
+		}
+	}
+	best.mu.Lock()
+	best.inflight++
+	best.mu.Unlock()
+	return best, nil
+}
+
+// check 查詢帳戶餘額和已打包的 nonce，暫停餘額不足或交易卡住的帳戶，恢復已正常的帳戶
+func (p *senderPool) check(ctx context.Context, client Backend, tracker *txTracker) {
+	for _, s := range p.senders {
+		address := s.address()
+		balance, err := client.BalanceAt(ctx, address, nil)
+		if err != nil {
+			log.Printf("Failed to check balance of sender %s: %v", address.Hex(), err)
+			continue
+		}
+		confirmed, err := client.NonceAt(ctx, address, nil)
+		if err != nil {
+			log.Printf("Failed to check nonce of sender %s: %v", address.Hex(), err)
+			continue
+		}
+		next, err := s.nonces.Next(ctx)
+		if err != nil {
+			log.Printf("Failed to check nonce of sender %s: %v", address.Hex(), err)
+			continue
+		}
+
+		var reason string
+		if min := p.opts.MinBalance; min != nil && min.Sign() > 0 && balance.Cmp(min) < 0 {
+			reason = fmt.Sprintf("balance %f ETH below minimum %f ETH", WeiToEth(balance), WeiToEth(min))
+		} else if next > confirmed && p.opts.StuckAfter > 0 {
+			// 下一個要被打包的交易等待太久，後面的交易都會被它擋住
+			if sentAt, ok := tracker.sentAt(address, confirmed); ok && time.Since(sentAt) > p.opts.StuckAfter {
+				reason = fmt.Sprintf("transaction with nonce %d pending for %s", confirmed, time.Since(sentAt).Round(time.Second))
+			}
+		}
+
+		s.mu.Lock()
+		if reason != s.reason {
+			if reason != "" {
+				log.Printf("Sender %s taken out of rotation: %s", address.Hex(), reason)
+			} else {
+				log.Printf("Sender %s back in rotation", address.Hex())
+			}
+		}
+		s.reason = reason
+		s.balance = balance
+		s.confirmed = confirmed
+		s.next = next
+		s.lastCheck = time.Now()
+		s.mu.Unlock()
+		tracker.settleBelow(address, confirmed)
+	}
+}
+
+func (p *senderPool) stats() []SenderStats {
+	stats := make([]SenderStats, 0, len(p.senders))
+	for _, s := range p.senders {
+		s.mu.Lock()
+		st := SenderStats{
+			Address:   s.address().Hex(),
+			Available: s.reason == "",
+			Reason:    s.reason,
+			Pending:   s.pending(),
+			NextNonce: s.next,
+			Sent:      s.sent,
+			Failed:    s.failed,
+		}
+		if s.balance != nil {
+			st.Balance = s.balance.String()
+		}
+		if !s.lastSent.IsZero() {
+			lastSent := s.lastSent
+			st.LastSent = &lastSent
+		}
+		if !s.lastCheck.IsZero() {
+			lastCheck := s.lastCheck
+			st.LastCheck = &lastCheck
+		}
+		s.mu.Unlock()
+		stats = append(stats, st)
+	}
+	return stats
+}
+
+// mined 收到收據後更新帳戶的等待數，並讓該 nonce 的交易記錄在保留期後被清理
+func (ci *ContractInteractor) mined(from common.Address, nonce uint64) {
+	ci.pool.mined(from, nonce)
+	ci.tracker.settle(from, nonce)
+}
+
+// SetPoolOptions 設置發送帳戶池的選項，應在開始處理請求前調用
+func (ci *ContractInteractor) SetPoolOptions(opts PoolOptions) {
+	ci.pool.opts = opts
+}
+
+// MonitorSenders 定期檢查發送帳戶，直到 ctx 被取消
+// 不運行時帳戶不會因餘額不足或交易卡住而被暫停
+func (ci *ContractInteractor) MonitorSenders(ctx context.Context) {
+	if ci.ReadOnly() {
+		return
+	}
+	ticker := time.NewTicker(ci.pool.opts.CheckInterval)
+	defer ticker.Stop()
+	for {
+		ci.pool.check(ctx, ci.client, ci.tracker)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SenderStats 返回每個發送帳戶的狀態和統計
+func (ci *ContractInteractor) SenderStats() []SenderStats {
+	return ci.pool.stats()
+}
@@ -0,0 +1,50 @@
+package contracts
+
+import (
+	"testing"
+
+	"Abby/signer"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSenderPoolCountsInflight(t *testing.T) {
+	var signers []signer.Signer
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, signer.NewKeySigner(key))
+	}
+	pool, err := newSenderPool(nil, signers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy, idle := pool.senders[0], pool.senders[1]
+	// busy 已有兩筆等待打包的交易
+	busy.record(1, nil)
+
+	// 還沒有拿到 nonce 的寫入也計入負載，並發寫入輪流分配到兩個帳戶
+	var chosen []*sender
+	for i := 0; i < 3; i++ {
+		s, err := pool.acquire()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chosen = append(chosen, s)
+	}
+	if chosen[0] != idle || chosen[1] != idle || chosen[2] != busy {
+		t.Fatalf("chosen = %s, %s, %s, want idle, idle, busy", chosen[0].address().Hex(), chosen[1].address().Hex(), chosen[2].address().Hex())
+	}
+
+	for _, s := range chosen {
+		s.release()
+	}
+	if s, _ := pool.peek(); s != idle {
+		t.Fatalf("after release peek = %s, want the idle sender", s.address().Hex())
+	}
+	if idle.inflight != 0 || busy.inflight != 0 {
+		t.Fatalf("inflight = %d, %d after release, want 0", idle.inflight, busy.inflight)
+	}
+}
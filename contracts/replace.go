@@ -0,0 +1,203 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ErrTxNotPending 交易已被打包，無法再替換
+	ErrTxNotPending = errors.New("transaction is no longer pending")
+	// ErrTxNotOwned 交易不是由本服務的發送帳戶發送的
+	ErrTxNotOwned = errors.New("transaction was not sent by a sender account of this service")
+)
+
+// 節點替換同一 nonce 交易時要求費用至少提高 10%
+const replacementBumpPercent = 10
+
+// SpeedUp 以相同的 nonce 和 calldata、更高的費用重新簽名並發送交易，廣播前調用 ctx 中的 BeforeSend
+func (ci *ContractInteractor) SpeedUp(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	original, err := ci.replaceableTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := ci.replacementFees(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+
+	return ci.sendReplacement(ctx, original, fees, original.To(), original.Value(), original.Gas(), original.Data())
+}
+
+// Cancel 在相同的 nonce 上發送一筆 0 ETH 的自我轉帳，使原交易失效，廣播前調用 ctx 中的 BeforeSend
+func (ci *ContractInteractor) Cancel(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	original, err := ci.replaceableTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := ci.replacementFees(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := txSender(original)
+	if err != nil {
+		return nil, err
+	}
+	return ci.sendReplacement(ctx, original, fees, &from, big.NewInt(0), params.TxGas, nil)
+}
+
+// replaceableTx 找到替換鏈上最新的交易，並確認它仍在等待打包且由帳戶池中的帳戶發送
+func (ci *ContractInteractor) replaceableTx(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	if ci.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	chain := ci.tracker.chain(hash)
+	for _, h := range chain {
+		_, err := ci.client.TransactionReceipt(ctx, h)
+		if err == nil {
+			return nil, ErrTxNotPending
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("failed to get transaction receipt: %w", classify(err))
+		}
+	}
+
+	latest := chain[len(chain)-1]
+	if tracked, ok := ci.tracker.get(latest); ok {
+		if ci.pool.lookup(tracked.from) == nil {
+			return nil, ErrTxNotOwned
+		}
+		return tracked.tx, nil
+	}
+
+	// 本地沒有記錄 (例如服務重啟過)，從節點讀取
+	tx, isPending, err := ci.client.TransactionByHash(ctx, latest)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, ErrTxNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", classify(err))
+	}
+	if !isPending {
+		return nil, ErrTxNotPending
+	}
+	from, err := txSender(tx)
+	if err != nil || ci.pool.lookup(from) == nil {
+		return nil, ErrTxNotOwned
+	}
+	return tx, nil
+}
+
+// replacementFees 計算替換交易的費用：取當前建議費用和原費用提高 10% 之間的較大值
+func (ci *ContractInteractor) replacementFees(ctx context.Context, original *types.Transaction) (*Fees, error) {
+	suggested, err := ci.feeStrategy.SuggestFees(ctx, ci.client)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees *Fees
+	if original.Type() == types.DynamicFeeTxType {
+		suggestedTip := suggested.GasTipCap
+		if suggestedTip == nil {
+			suggestedTip = suggested.GasPrice
+		}
+		tip := maxBig(bumpFee(original.GasTipCap()), suggestedTip)
+		feeCap := maxBig(bumpFee(original.GasFeeCap()), suggested.MaxPricePerGas())
+		if feeCap.Cmp(tip) < 0 {
+			feeCap = tip
+		}
+		fees = &Fees{GasTipCap: tip, GasFeeCap: feeCap, BaseFee: suggested.BaseFee}
+	} else {
+		fees = &Fees{GasPrice: maxBig(bumpFee(original.GasPrice()), suggested.MaxPricePerGas())}
+	}
+
+	if maxFee := ci.feeStrategy.MaxFeeCap; maxFee != nil && fees.MaxPricePerGas().Cmp(maxFee) > 0 {
+		return nil, fmt.Errorf("replacement fee %s Wei exceeds configured max fee %s Wei", fees.MaxPricePerGas(), maxFee)
+	}
+	return fees, nil
+}
+
+// sendReplacement 以原交易的 nonce 簽名並發送替換交易，並記錄替換關係
+func (ci *ContractInteractor) sendReplacement(ctx context.Context, original *types.Transaction, fees *Fees, to *common.Address, value *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	var inner types.TxData
+	if fees.Dynamic() {
+		inner = &types.DynamicFeeTx{
+			ChainID:   original.ChainId(),
+			Nonce:     original.Nonce(),
+			GasTipCap: fees.GasTipCap,
+			GasFeeCap: fees.GasFeeCap,
+			Gas:       gas,
+			To:        to,
+			Value:     value,
+			Data:      data,
+		}
+	} else {
+		inner = &types.LegacyTx{
+			Nonce:    original.Nonce(),
+			GasPrice: fees.GasPrice,
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+
+	from, err := txSender(original)
+	if err != nil {
+		return nil, err
+	}
+	sender := ci.pool.lookup(from)
+	if sender == nil {
+		return nil, ErrTxNotOwned
+	}
+
+	signed, err := sender.signer.SignTx(ctx, types.NewTx(inner), ci.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement transaction: %w", err)
+	}
+	if err := beforeSend(ctx, signed); err != nil {
+		return nil, err
+	}
+	if err := ci.client.SendTransaction(ctx, signed); err != nil {
+		return nil, fmt.Errorf("failed to send replacement transaction: %w", classify(err))
+	}
+
+	ci.tracker.replace(original, signed, from)
+
+	log.Printf("Transaction %s replaced by %s (nonce %d, %s)", original.Hash().Hex(), signed.Hash().Hex(), signed.Nonce(), fees)
+	return signed, nil
+}
+
+// txSender 從簽名中恢復交易的發送帳戶
+func txSender(tx *types.Transaction) (common.Address, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover sender: %v", err)
+	}
+	return from, nil
+}
+
+// bumpFee 返回比原費用高出 replacementBumpPercent 的費用
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+replacementBumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	return bumped.Add(bumped, big.NewInt(1))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if b != nil && a.Cmp(b) < 0 {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int).Set(a)
+}
@@ -0,0 +1,116 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSpeedUp(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	original, err := ci.SendSetValue(ctx, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	replacement, err := ci.SpeedUp(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("SpeedUp: %v", err)
+	}
+
+	if replacement.Nonce() != original.Nonce() || !bytes.Equal(replacement.Data(), original.Data()) {
+		t.Fatal("replacement does not reuse the nonce and calldata")
+	}
+	for name, fee := range map[string][2]*big.Int{
+		"tip":     {original.GasTipCap(), replacement.GasTipCap()},
+		"fee cap": {original.GasFeeCap(), replacement.GasFeeCap()},
+	} {
+		if min := bumpFee(fee[0]); fee[1].Cmp(min) < 0 {
+			t.Fatalf("%s %s is below the required bump %s", name, fee[1], min)
+		}
+	}
+
+	chain.backend.Commit()
+	if _, err := chain.client.TransactionReceipt(ctx, replacement.Hash()); err != nil {
+		t.Fatalf("replacement not mined: %v", err)
+	}
+	value, err := ci.GetValue()
+	if err != nil || value.Int64() != 5 {
+		t.Fatalf("value = %v, %v, want 5", value, err)
+	}
+
+	if _, err := ci.SpeedUp(ctx, original.Hash()); !errors.Is(err, ErrTxNotPending) {
+		t.Fatalf("SpeedUp of a mined transaction: err = %v, want ErrTxNotPending", err)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	original, err := ci.SendSetValue(ctx, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	cancel, err := ci.Cancel(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if cancel.Nonce() != original.Nonce() || *cancel.To() != chain.address(1) || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 {
+		t.Fatalf("cancel is not a 0 ETH self-transfer with nonce %d", original.Nonce())
+	}
+
+	chain.backend.Commit()
+	status, err := ci.TxStatus(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("TxStatus: %v", err)
+	}
+	if status.Status != TxStatusMined || status.ReplacedBy != cancel.Hash().Hex() {
+		t.Fatalf("status = %+v, want mined by %s", status, cancel.Hash().Hex())
+	}
+	value, err := ci.GetValue()
+	if err != nil || value.Sign() != 0 {
+		t.Fatalf("value = %v, %v, want the original value 0", value, err)
+	}
+}
+
+func TestReplaceErrors(t *testing.T) {
+	chain := newTestChain(t, 3, nil)
+	address := chain.deploy(t)
+	ci := chain.interactor(t, address, 1)
+	ctx := context.Background()
+
+	if _, err := ci.SpeedUp(ctx, common.HexToHash("0x01")); !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("unknown transaction: err = %v, want ErrTxNotFound", err)
+	}
+
+	// 其他帳戶發送、仍在等待打包的交易
+	other := types.MustSignNewTx(chain.keys[2], types.LatestSignerForChainID(chain.chainID(t)), &types.DynamicFeeTx{
+		ChainID:   chain.chainID(t),
+		Nonce:     0,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(1e11),
+		Gas:       21000,
+		To:        &address,
+		Value:     big.NewInt(0),
+	})
+	if err := chain.client.SendTransaction(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ci.Cancel(ctx, other.Hash()); !errors.Is(err, ErrTxNotOwned) {
+		t.Fatalf("foreign transaction: err = %v, want ErrTxNotOwned", err)
+	}
+
+	readOnly := chain.interactor(t, address)
+	if _, err := readOnly.SpeedUp(ctx, other.Hash()); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("read-only: err = %v, want ErrReadOnly", err)
+	}
+}
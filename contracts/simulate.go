@@ -0,0 +1,182 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 回滾原因的類型
+const (
+	// RevertKindError 合約以 require/revert 的 Error(string) 回滾
+	RevertKindError = "error"
+	// RevertKindPanic 合約以 Panic(uint256) 回滾，例如 assert 失敗或算術溢出
+	RevertKindPanic = "panic"
+	// RevertKindCustom 合約以 ABI 中定義的自定義錯誤回滾
+	RevertKindCustom = "custom"
+	// RevertKindUnknown 沒有回滾數據，或數據無法按 ABI 解碼
+	RevertKindUnknown = "unknown"
+)
+
+// ErrReverted 交易在預執行時回滾，沒有發送
+var ErrReverted = errors.New("execution reverted")
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// RevertError 預執行回滾的原因，errors.Is(err, ErrReverted) 為 true
+type RevertError struct {
+	// Kind 回滾類型：error、panic、custom 或 unknown
+	Kind string `json:"kind" example:"error"`
+	// Reason 解碼後的原因，自定義錯誤格式為 Name(arg, ...)
+	Reason string `json:"reason,omitempty" example:"value too large"`
+	// Data 原始回滾數據
+	Data hexutil.Bytes `json:"data,omitempty" swaggertype:"string" example:"0x08c379a0"`
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return ErrReverted.Error()
+	}
+	return fmt.Sprintf("%v: %s", ErrReverted, e.Reason)
+}
+
+func (e *RevertError) Unwrap() error {
+	return ErrReverted
+}
+
+// Simulation 寫入交易在 pending 區塊上預執行的結果
+type Simulation struct {
+	From string `json:"from" example:"0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"`
+	To   string `json:"to" example:"0x5FbDB2315678afecb367f032d93F642f64180aa3"`
+	// Data 交易的 calldata
+	Data    hexutil.Bytes `json:"data" swaggertype:"string" example:"0x60fe47b1000000000000000000000000000000000000000000000000000000000000002a"`
+	Success bool          `json:"success" example:"true"`
+	// GasEstimate 預估的 gas 用量，回滾時為 0
+	GasEstimate uint64       `json:"gasEstimate,omitempty" example:"26524"`
+	Revert      *RevertError `json:"revert,omitempty"`
+}
+
+// SimulateSetValue 以下一筆寫入會使用的發送帳戶預執行 set，不發送交易
+func (ci *ContractInteractor) SimulateSetValue(ctx context.Context, value *big.Int) (*Simulation, error) {
+	if ci.ReadOnly() {
+		return nil, ErrReadOnly
+	}
+	sender, err := ci.pool.peek()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ci.setCalldata(value)
+	if err != nil {
+		return nil, err
+	}
+	return ci.simulate(ctx, sender.address(), data)
+}
+
+// setCalldata 返回 set(value) 的 calldata
+func (ci *ContractInteractor) setCalldata(value *big.Int) ([]byte, error) {
+	parsed, err := ContractsMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Pack("set", value)
+}
+
+// simulate 在 pending 區塊上以 eth_call 執行交易並估算 gas，回滾時返回的 Simulation 包含解碼後的原因
+// 只有 RPC 本身失敗時才返回錯誤
+func (ci *ContractInteractor) simulate(ctx context.Context, from common.Address, data []byte) (*Simulation, error) {
+	msg := ethereum.CallMsg{From: from, To: &ci.address, Data: data}
+	sim := &Simulation{From: from.Hex(), To: ci.address.Hex(), Data: data}
+
+	if _, err := ci.client.CallContract(ctx, msg, big.NewInt(int64(rpc.PendingBlockNumber))); err != nil {
+		revert, ok := decodeRevert(err)
+		if !ok {
+			return nil, fmt.Errorf("failed to simulate transaction: %w", classify(err))
+		}
+		sim.Revert = revert
+		return sim, nil
+	}
+
+	gas, err := ci.client.EstimateGas(ctx, msg)
+	if err != nil {
+		revert, ok := decodeRevert(err)
+		if !ok {
+			return nil, fmt.Errorf("failed to estimate gas: %w", classify(err))
+		}
+		sim.Revert = revert
+		return sim, nil
+	}
+	sim.Success = true
+	sim.GasEstimate = gas
+	return sim, nil
+}
+
+// decodeRevert 從 eth_call 的錯誤中取出回滾數據並解碼，不是回滾的錯誤返回 false
+func decodeRevert(err error) (*RevertError, bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(hexData); decodeErr == nil {
+				return unpackRevert(data), true
+			}
+		}
+	}
+	// 沒有回滾數據，例如 revert() 或節點不返回數據
+	if strings.Contains(err.Error(), "execution reverted") {
+		return &RevertError{Kind: RevertKindUnknown}, true
+	}
+	return nil, false
+}
+
+// unpackRevert 把回滾數據解碼為 Error(string)、Panic(uint256) 或 ABI 中的自定義錯誤
+func unpackRevert(data []byte) *RevertError {
+	revert := &RevertError{Kind: RevertKindUnknown, Data: data}
+	if len(data) < 4 {
+		return revert
+	}
+
+	switch selector := data[:4]; {
+	case bytes.Equal(selector, errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revert.Kind, revert.Reason = RevertKindError, reason
+		}
+	case bytes.Equal(selector, panicSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			revert.Kind, revert.Reason = RevertKindPanic, reason
+		}
+	default:
+		parsed, err := ContractsMetaData.GetAbi()
+		if err != nil {
+			return revert
+		}
+		for _, abiErr := range parsed.Errors {
+			if !bytes.Equal(selector, abiErr.ID[:4]) {
+				continue
+			}
+			values, err := abiErr.Inputs.Unpack(data[4:])
+			if err != nil {
+				return revert
+			}
+			args := make([]string, len(values))
+			for i, v := range values {
+				args[i] = fmt.Sprint(v)
+			}
+			revert.Kind = RevertKindCustom
+			revert.Reason = fmt.Sprintf("%s(%s)", abiErr.Name, strings.Join(args, ", "))
+			return revert
+		}
+	}
+	return revert
+}
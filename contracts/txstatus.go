@@ -0,0 +1,317 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// 交易狀態
+const (
+	TxStatusPending = "pending"
+	TxStatusMined   = "mined"
+	TxStatusFailed  = "failed"
+	TxStatusDropped = "dropped"
+)
+
+// ErrTxNotFound 節點和本地記錄中都找不到該交易
+var ErrTxNotFound = errors.New("transaction not found")
+
+// TxStatus 交易的當前狀態
+// 如果交易已被加速或取消，ReplacedBy 為實際生效的替換交易，其餘欄位描述的是該替換交易
+type TxStatus struct {
+	Hash          string `json:"hash" example:"0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"`
+	ReplacedBy    string `json:"replacedBy,omitempty" example:"0x0b6d4f5c3a8e7d2f1e9c8b7a6d5e4f3c2b1a0f9e8d7c6b5a4f3e2d1c0b9a8f7e"`
+	Status        string `json:"status" example:"mined" enums:"pending,mined,failed,dropped"`
+	Nonce         uint64 `json:"nonce" example:"7"`
+	BlockNumber   uint64 `json:"blockNumber,omitempty" example:"5234567"`
+	GasUsed       uint64 `json:"gasUsed,omitempty" example:"26734"`
+	Confirmations uint64 `json:"confirmations" example:"3"`
+}
+
+// 本地交易記錄的保留時間
+const (
+	// trackerRetention nonce 已被打包或交易被丟棄後，記錄再保留的時間，期間仍可沿替換鏈查詢狀態
+	trackerRetention = time.Hour
+	// trackerTTL 任何記錄的最長保留時間，包括一直沒有結果的交易
+	trackerTTL = 24 * time.Hour
+	// trackerPruneInterval 兩次清理過期記錄的最短間隔
+	trackerPruneInterval = time.Minute
+)
+
+// trackedTx 本地記錄的已發送交易
+type trackedTx struct {
+	tx         *types.Transaction
+	from       common.Address
+	sentAt     time.Time
+	replacedBy common.Hash
+}
+
+// nonceKey 發送帳戶和 nonce，同一個 nonce 上的原交易和替換交易共用一個鍵
+type nonceKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+// nonceTxs 同一個帳戶和 nonce 上發送過的交易
+type nonceTxs struct {
+	hashes []common.Hash
+	// sentAt 最近一次發送的時間，替換交易會重新計時
+	sentAt time.Time
+	// settledAt 該 nonce 已被打包或交易被丟棄的時間，零值表示仍在等待
+	settledAt time.Time
+}
+
+// txTracker 記錄由本服務發送的交易，用於判斷交易是否被丟棄以及追蹤替換交易
+// 已有結果的記錄在 trackerRetention 之後清理，其他記錄最多保留 trackerTTL
+type txTracker struct {
+	mu      sync.RWMutex
+	txs     map[common.Hash]trackedTx
+	byNonce map[nonceKey]*nonceTxs
+	// settledBelow 每個帳戶已標記為打包的 nonce 上界，從第一筆記錄的 nonce 開始
+	settledBelow map[common.Address]uint64
+	lastPrune    time.Time
+}
+
+func newTxTracker() *txTracker {
+	return &txTracker{
+		txs:          make(map[common.Hash]trackedTx),
+		byNonce:      make(map[nonceKey]*nonceTxs),
+		settledBelow: make(map[common.Address]uint64),
+		lastPrune:    time.Now(),
+	}
+}
+
+func (t *txTracker) track(tx *types.Transaction, from common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(tx, from, time.Now())
+}
+
+// replace 記錄 replacement 以相同 nonce 替換了 original
+func (t *txTracker) replace(original, replacement *types.Transaction, from common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	tracked, ok := t.txs[original.Hash()]
+	if !ok {
+		t.add(original, from, now)
+		tracked = t.txs[original.Hash()]
+	}
+	tracked.replacedBy = replacement.Hash()
+	t.txs[original.Hash()] = tracked
+	t.add(replacement, from, now)
+}
+
+// add 記錄一筆交易並順便清理過期記錄，調用時應持有寫鎖
+func (t *txTracker) add(tx *types.Transaction, from common.Address, now time.Time) {
+	if now.Sub(t.lastPrune) >= trackerPruneInterval {
+		t.prune(now)
+	}
+	t.txs[tx.Hash()] = trackedTx{
+		tx:     tx,
+		from:   from,
+		sentAt: now,
+	}
+	if _, ok := t.settledBelow[from]; !ok {
+		t.settledBelow[from] = tx.Nonce()
+	}
+	key := nonceKey{from, tx.Nonce()}
+	entry, ok := t.byNonce[key]
+	if !ok {
+		entry = &nonceTxs{}
+		t.byNonce[key] = entry
+	}
+	entry.hashes = append(entry.hashes, tx.Hash())
+	entry.sentAt = now
+}
+
+// prune 刪除已有結果超過 trackerRetention 或發送超過 trackerTTL 的記錄，調用時應持有寫鎖
+func (t *txTracker) prune(now time.Time) {
+	t.lastPrune = now
+	for key, entry := range t.byNonce {
+		settled := !entry.settledAt.IsZero() && now.Sub(entry.settledAt) > trackerRetention
+		if !settled && now.Sub(entry.sentAt) <= trackerTTL {
+			continue
+		}
+		for _, hash := range entry.hashes {
+			delete(t.txs, hash)
+		}
+		delete(t.byNonce, key)
+	}
+}
+
+func (t *txTracker) get(hash common.Hash) (trackedTx, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	tracked, ok := t.txs[hash]
+	return tracked, ok
+}
+
+// sentAt 返回 from 以 nonce 發送的交易中最近一次的發送時間，替換交易會重新計時
+func (t *txTracker) sentAt(from common.Address, nonce uint64) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.byNonce[nonceKey{from, nonce}]
+	if !ok {
+		return time.Time{}, false
+	}
+	return entry.sentAt, true
+}
+
+// settle 標記 from 在 nonce 上的交易已被打包或丟棄，之後會被清理
+func (t *txTracker) settle(from common.Address, nonce uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.settleLocked(nonceKey{from, nonce}, time.Now())
+}
+
+// settleBelow 標記 from 小於 confirmed 的 nonce 都已被打包
+func (t *txTracker) settleBelow(from common.Address, confirmed uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	below, ok := t.settledBelow[from]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	for nonce := below; nonce < confirmed; nonce++ {
+		t.settleLocked(nonceKey{from, nonce}, now)
+	}
+	t.settledBelow[from] = max(below, confirmed)
+}
+
+func (t *txTracker) settleLocked(key nonceKey, now time.Time) {
+	if entry, ok := t.byNonce[key]; ok && entry.settledAt.IsZero() {
+		entry.settledAt = now
+	}
+}
+
+// chain 返回從 hash 開始的替換鏈，第一個是 hash 本身，最後一個是最新的替換交易
+func (t *txTracker) chain(hash common.Hash) []common.Hash {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	chain := []common.Hash{hash}
+	for {
+		tracked, ok := t.txs[chain[len(chain)-1]]
+		if !ok || tracked.replacedBy == (common.Hash{}) {
+			return chain
+		}
+		chain = append(chain, tracked.replacedBy)
+	}
+}
+
+// TxStatus 查詢交易的狀態：pending、mined、failed 或 dropped
+// 對於被加速或取消的交易，會沿著替換鏈找到實際生效的交易
+func (ci *ContractInteractor) TxStatus(ctx context.Context, hash common.Hash) (*TxStatus, error) {
+	chain := ci.tracker.chain(hash)
+
+	// 同一個 nonce 只會有一筆交易被打包，先找有收據的那一筆
+	for i := len(chain) - 1; i >= 0; i-- {
+		receipt, err := ci.client.TransactionReceipt(ctx, chain[i])
+		if err == nil {
+			return ci.minedStatus(ctx, hash, chain[i], receipt)
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, fmt.Errorf("failed to get transaction receipt: %w", classify(err))
+		}
+	}
+
+	// 沒有收據，檢查最新的替換交易是否仍在交易池中
+	latest := chain[len(chain)-1]
+	status := &TxStatus{Hash: hash.Hex()}
+	if latest != hash {
+		status.ReplacedBy = latest.Hex()
+	}
+
+	tx, _, err := ci.client.TransactionByHash(ctx, latest)
+	if err == nil {
+		status.Status = TxStatusPending
+		status.Nonce = tx.Nonce()
+		return status, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("failed to get transaction: %w", classify(err))
+	}
+
+	// 節點已不認識該交易，只有本地發送過的交易才能判斷為 dropped
+	tracked, ok := ci.tracker.get(latest)
+	if !ok {
+		return nil, ErrTxNotFound
+	}
+	// 交易被丟棄意味著本地 nonce 可能出現空洞，下次發送前重新同步
+	if s := ci.pool.lookup(tracked.from); s != nil {
+		s.nonces.Reset()
+	}
+	ci.tracker.settle(tracked.from, tracked.tx.Nonce())
+	status.Status = TxStatusDropped
+	status.Nonce = tracked.tx.Nonce()
+	return status, nil
+}
+
+func (ci *ContractInteractor) minedStatus(ctx context.Context, requested, mined common.Hash, receipt *types.Receipt) (*TxStatus, error) {
+	head, err := ci.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", classify(err))
+	}
+
+	status := &TxStatus{
+		Hash:        requested.Hex(),
+		Status:      TxStatusMined,
+		BlockNumber: receipt.BlockNumber.Uint64(),
+		GasUsed:     receipt.GasUsed,
+	}
+	if mined != requested {
+		status.ReplacedBy = mined.Hex()
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		status.Status = TxStatusFailed
+	}
+	if head >= status.BlockNumber {
+		status.Confirmations = head - status.BlockNumber + 1
+	}
+
+	// 收據中沒有 nonce，從交易本身讀取
+	if tracked, ok := ci.tracker.get(mined); ok {
+		status.Nonce = tracked.tx.Nonce()
+		ci.mined(tracked.from, status.Nonce)
+	} else if tx, _, err := ci.client.TransactionByHash(ctx, mined); err == nil {
+		status.Nonce = tx.Nonce()
+	}
+	return status, nil
+}
+
+// TxCost 返回交易實際花費的 gas 費用 (gasUsed × effectiveGasPrice)，done 為 false 表示交易還在等待打包
+// 交易被替換交易取代或被丟棄時返回 0 和 true，費用算在實際被打包的交易上
+func (ci *ContractInteractor) TxCost(ctx context.Context, hash common.Hash) (*big.Int, bool, error) {
+	receipt, err := ci.client.TransactionReceipt(ctx, hash)
+	if err == nil {
+		cost := new(big.Int).SetUint64(receipt.GasUsed)
+		if receipt.EffectiveGasPrice != nil {
+			cost.Mul(cost, receipt.EffectiveGasPrice)
+		}
+		return cost, true, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return nil, false, fmt.Errorf("failed to get transaction receipt: %w", classify(err))
+	}
+
+	status, err := ci.TxStatus(ctx, hash)
+	if errors.Is(err, ErrTxNotFound) {
+		return new(big.Int), true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if status.Status == TxStatusPending {
+		return nil, false, nil
+	}
+	return new(big.Int), true, nil
+}
@@ -0,0 +1,156 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxStatusMined(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	tx, err := ci.SendSetValue(ctx, big.NewInt(7))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	chain.backend.Commit()
+	chain.backend.Commit()
+
+	status, err := ci.TxStatus(ctx, tx.Hash())
+	if err != nil {
+		t.Fatalf("TxStatus: %v", err)
+	}
+	if status.Status != TxStatusMined || status.ReplacedBy != "" {
+		t.Fatalf("status = %+v, want mined without replacement", status)
+	}
+	if status.Nonce != tx.Nonce() || status.GasUsed == 0 || status.Confirmations != 2 {
+		t.Fatalf("status = %+v, want nonce %d, gas used and 2 confirmations", status, tx.Nonce())
+	}
+}
+
+func TestTxStatusNotFound(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+
+	_, err := ci.TxStatus(context.Background(), common.HexToHash("0x01"))
+	if !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("err = %v, want ErrTxNotFound", err)
+	}
+}
+
+func TestTxStatusReplacementChain(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	original, err := ci.SendSetValue(ctx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	first, err := ci.SpeedUp(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("SpeedUp: %v", err)
+	}
+	// 加速原交易時沿替換鏈找到最新的交易再替換
+	second, err := ci.SpeedUp(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("second SpeedUp: %v", err)
+	}
+
+	status, err := ci.TxStatus(ctx, original.Hash())
+	if err != nil {
+		t.Fatalf("TxStatus: %v", err)
+	}
+	if status.Status != TxStatusPending || status.ReplacedBy != second.Hash().Hex() {
+		t.Fatalf("status = %+v, want pending and replaced by %s", status, second.Hash().Hex())
+	}
+
+	chain.backend.Commit()
+	for _, hash := range []common.Hash{original.Hash(), first.Hash()} {
+		status, err := ci.TxStatus(ctx, hash)
+		if err != nil {
+			t.Fatalf("TxStatus: %v", err)
+		}
+		if status.Status != TxStatusMined || status.ReplacedBy != second.Hash().Hex() || status.Nonce != original.Nonce() {
+			t.Fatalf("status of %s = %+v, want mined by %s", hash.Hex(), status, second.Hash().Hex())
+		}
+	}
+}
+
+func TestTxStatusDroppedResetsNonce(t *testing.T) {
+	chain := newTestChain(t, 2, nil)
+	ci := chain.interactor(t, chain.deploy(t), 1)
+	ctx := context.Background()
+
+	dropped, err := ci.SendSetValue(ctx, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("SendSetValue: %v", err)
+	}
+	// 清空交易池，交易沒有被打包就消失了
+	chain.backend.Rollback()
+
+	status, err := ci.TxStatus(ctx, dropped.Hash())
+	if err != nil {
+		t.Fatalf("TxStatus: %v", err)
+	}
+	if status.Status != TxStatusDropped || status.Nonce != dropped.Nonce() {
+		t.Fatalf("status = %+v, want dropped with nonce %d", status, dropped.Nonce())
+	}
+
+	// 發現交易被丟棄後，下次發送前重新向節點同步 nonce，而不是繼續使用空洞之後的 nonce
+	// 模擬鏈清空交易池時不重置它的 pending nonce，這裡直接檢查 nonce 管理器
+	nonces := ci.pool.lookup(chain.address(1)).nonces
+	nonces.mu.Lock()
+	synced := nonces.synced
+	nonces.mu.Unlock()
+	if synced {
+		t.Fatal("nonce manager was not reset after the transaction was dropped")
+	}
+}
+
+func TestTxTrackerPrune(t *testing.T) {
+	tracker := newTxTracker()
+	from := common.HexToAddress("0x01")
+	newTx := func(nonce uint64, tip int64) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{Nonce: nonce, GasTipCap: big.NewInt(tip), GasFeeCap: big.NewInt(tip)})
+	}
+	mined, replacement, pending := newTx(5, 1), newTx(5, 2), newTx(6, 1)
+	tracker.track(mined, from)
+	tracker.replace(mined, replacement, from)
+	tracker.track(pending, from)
+
+	if _, ok := tracker.sentAt(from, 6); !ok {
+		t.Fatal("sentAt did not find nonce 6")
+	}
+	// 只有 nonce 5 已被打包，保留期內仍能沿替換鏈查詢
+	tracker.settleBelow(from, 6)
+	tracker.prune(time.Now())
+	if chain := tracker.chain(mined.Hash()); len(chain) != 2 || chain[1] != replacement.Hash() {
+		t.Fatalf("chain within retention = %v, want the replacement", chain)
+	}
+
+	tracker.prune(time.Now().Add(trackerRetention + time.Minute))
+	for _, tx := range []*types.Transaction{mined, replacement} {
+		if _, ok := tracker.get(tx.Hash()); ok {
+			t.Fatalf("settled transaction %s was not pruned", tx.Hash().Hex())
+		}
+	}
+	if _, ok := tracker.sentAt(from, 5); ok {
+		t.Fatal("nonce index of settled transactions was not pruned")
+	}
+	if _, ok := tracker.get(pending.Hash()); !ok {
+		t.Fatal("pending transaction was pruned before the TTL")
+	}
+
+	tracker.prune(time.Now().Add(trackerTTL + time.Minute))
+	if len(tracker.txs) != 0 || len(tracker.byNonce) != 0 {
+		t.Fatalf("%d transactions left after the TTL", len(tracker.txs))
+	}
+}
@@ -0,0 +1,59 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrNoCode 地址上沒有合約代碼
+	ErrNoCode = errors.New("no contract code at address")
+	// ErrCodeMismatch 地址上的代碼與 ContractsBin 部署出的代碼不同
+	ErrCodeMismatch = errors.New("contract code does not match SimpleStorage")
+)
+
+var runtimeCode struct {
+	once sync.Once
+	code []byte
+	err  error
+}
+
+// RuntimeBytecode 在本地 EVM 中執行 ContractsBin 的創建代碼，返回部署後地址上應有的運行時字節碼
+func RuntimeBytecode() ([]byte, error) {
+	runtimeCode.once.Do(func() {
+		code, _, _, err := runtime.Create(common.FromHex(ContractsBin), nil)
+		if err != nil {
+			runtimeCode.err = fmt.Errorf("failed to derive runtime bytecode: %v", err)
+			return
+		}
+		runtimeCode.code = code
+	})
+	return runtimeCode.code, runtimeCode.err
+}
+
+// VerifyCode 檢查 address 上的代碼是否為 SimpleStorage，沒有代碼時返回 ErrNoCode，代碼不同時返回 ErrCodeMismatch
+func VerifyCode(ctx context.Context, client Backend, address common.Address) error {
+	expected, err := RuntimeBytecode()
+	if err != nil {
+		return err
+	}
+
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get code at %s: %w", address.Hex(), classify(err))
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("%w %s", ErrNoCode, address.Hex())
+	}
+	if !bytes.Equal(code, expected) {
+		return fmt.Errorf("%w: code hash at %s is %s, expected %s", ErrCodeMismatch, address.Hex(), crypto.Keccak256Hash(code).Hex(), crypto.Keccak256Hash(expected).Hex())
+	}
+	return nil
+}
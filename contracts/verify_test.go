@@ -0,0 +1,38 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestVerifyCode(t *testing.T) {
+	// 創世區塊中放一個代碼不是 SimpleStorage 的合約
+	other := common.HexToAddress("0x00000000000000000000000000000000000000c0")
+	chain := newTestChain(t, 1, types.GenesisAlloc{
+		other: {Code: []byte{0x60, 0x00, 0x60, 0x00, 0xf3}},
+	})
+	deployed := chain.deploy(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		address common.Address
+		want    error
+	}{
+		{"simple storage", deployed, nil},
+		{"no code", common.HexToAddress("0x00000000000000000000000000000000000000c1"), ErrNoCode},
+		{"other contract", other, ErrCodeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyCode(ctx, chain.client, tt.address)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("VerifyCode = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package devchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Options 模擬鏈的選項
+type Options struct {
+	// Accounts 預先充值的帳戶數量
+	Accounts int
+	// Balance 每個帳戶的初始餘額 (wei)
+	Balance *big.Int
+	// BlockTime 出塊間隔，0 表示每收到一筆交易立即出塊 (auto-mining)
+	BlockTime time.Duration
+	// GasLimit 每個區塊的 gas 上限
+	GasLimit uint64
+}
+
+// DefaultOptions 返回預設選項：10 個帳戶，每個 1000 ETH，auto-mining
+func DefaultOptions() Options {
+	return Options{
+		Accounts: 10,
+		Balance:  new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether)),
+		GasLimit: 30_000_000,
+	}
+}
+
+// Account 預先充值的帳戶
+type Account struct {
+	Address    common.Address
+	PrivateKey *ecdsa.PrivateKey
+}
+
+// PrivateKeyHex 返回不帶 0x 前綴的私鑰，與 PRIVATE_KEY 的格式相同
+func (a Account) PrivateKeyHex() string {
+	return common.Bytes2Hex(crypto.FromECDSA(a.PrivateKey))
+}
+
+// Chain 基於 go-ethereum simulated backend 的進程內模擬鏈，用於離線開發，滿足 contracts.Backend
+type Chain struct {
+	simulated.Client
+
+	Accounts []Account
+
+	backend  *simulated.Backend
+	chainID  *big.Int
+	autoMine bool
+	mu       sync.Mutex // 串行化發送交易和出塊
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+var _ contracts.Backend = (*Chain)(nil)
+
+// New 創建模擬鏈並為帳戶充值，BlockTime 大於 0 時啟動定時出塊
+func New(opts Options) (*Chain, error) {
+	if opts.Accounts <= 0 {
+		return nil, fmt.Errorf("at least one account is required")
+	}
+
+	alloc := make(types.GenesisAlloc)
+	accounts := make([]Account, 0, opts.Accounts)
+	for i := 0; i < opts.Accounts; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %v", err)
+		}
+		account := Account{
+			Address:    crypto.PubkeyToAddress(key.PublicKey),
+			PrivateKey: key,
+		}
+		accounts = append(accounts, account)
+		alloc[account.Address] = types.Account{Balance: opts.Balance}
+	}
+
+	backend := simulated.NewBackend(alloc, simulated.WithBlockGasLimit(opts.GasLimit))
+	// 創世區塊不是 post-merge 區塊，先出一個空塊讓之後的調用按 Shanghai 之後的規則執行 (合約字節碼使用了 PUSH0)
+	backend.Commit()
+
+	chainID, err := backend.Client().ChainID(context.Background())
+	if err != nil {
+		backend.Close()
+		return nil, fmt.Errorf("failed to get chain id: %v", err)
+	}
+
+	chain := &Chain{
+		Client:   backend.Client(),
+		Accounts: accounts,
+		backend:  backend,
+		chainID:  chainID,
+		autoMine: opts.BlockTime <= 0,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if chain.autoMine {
+		close(chain.done)
+	} else {
+		go chain.mineLoop(opts.BlockTime)
+	}
+	return chain, nil
+}
+
+// SendTransaction 將交易加入待打包區塊，auto-mining 模式下立即出塊
+func (c *Chain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	if c.autoMine {
+		c.backend.Commit()
+	}
+	return nil
+}
+
+// Commit 手動出塊
+func (c *Chain) Commit() common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backend.Commit()
+}
+
+// Close 停止定時出塊並關閉模擬鏈
+func (c *Chain) Close() error {
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.done
+	return c.backend.Close()
+}
+
+func (c *Chain) mineLoop(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Commit()
+		}
+	}
+}
+
+// DeploySimpleStorage 使用第一個帳戶部署 SimpleStorage 合約並等待交易被打包
+func (c *Chain) DeploySimpleStorage(ctx context.Context) (common.Address, error) {
+	auth, err := bind.NewKeyedTransactorWithChainID(c.Accounts[0].PrivateKey, c.chainID)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to create transactor: %v", err)
+	}
+
+	address, tx, _, err := contracts.DeployContracts(auth, c)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to deploy contract: %v", err)
+	}
+
+	if _, err := bind.WaitDeployed(ctx, c, tx); err != nil {
+		return common.Address{}, fmt.Errorf("failed to wait for deployment: %v", err)
+	}
+
+	log.Printf("SimpleStorage deployed on dev chain at %s", address.Hex())
+	return address, nil
+}
@@ -15,51 +15,443 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/storage/value": {
+        "/senders": {
             "get": {
-                "description": "從智能合約中獲取當前存儲的值",
+                "description": "列出發送帳戶池中每個帳戶的餘額、等待打包的交易數、已發送和失敗的交易數，以及是否因餘額不足或交易卡住而暫停使用",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "查詢發送帳戶",
+                "responses": {
+                    "200": {
+                        "description": "發送帳戶",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/contracts.SenderStats"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 admin 權限",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/storage/events": {
+            "get": {
+                "description": "以 Server-Sent Events 推送每個新的 DataStored 事件。事件 ID 可通過 Last-Event-ID 頭或 lastEventId 參數在重新連接時補發錯過的事件，也可用 fromBlock 從指定區塊開始補發。鏈重組時推送 reorg 事件",
+                "produces": [
+                    "text/event-stream",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "訂閱新的值 (SSE)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上次收到的事件 ID",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次收到的事件 ID (瀏覽器 EventSource 無法設置請求頭時使用)",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "從該區塊開始補發事件",
+                        "name": "fromBlock",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "事件流",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "參數錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 storage:read 權限",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過該路由的請求速率，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "未啟用事件索引 (indexing_disabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/storage/events/ws": {
+            "get": {
+                "description": "與 /storage/events 相同的事件流，每條消息為一個 StreamMessage JSON。用 lastEventId 或 fromBlock 參數補發錯過的事件",
+                "tags": [
+                    "storage"
+                ],
+                "summary": "訂閱新的值 (WebSocket)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上次收到的事件 ID",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "從該區塊開始補發事件",
+                        "name": "fromBlock",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "升級為 WebSocket，之後推送 StreamMessage",
+                        "schema": {
+                            "$ref": "#/definitions/api.StreamMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "參數錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 storage:read 權限，或網頁來源不在 auth.allowedOrigins 中",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過該路由的請求速率，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "未啟用事件索引 (indexing_disabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/storage/history": {
+            "get": {
+                "description": "從已索引的 DataStored 事件中分頁查詢歷史值。索引尚未追上最新區塊時 complete 為 false，indexing 返回當前進度",
+                "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "storage"
+                ],
+                "summary": "獲取歷史值",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上一頁返回的 nextCursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每頁數量 (預設 50，最多 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "排序",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "起始區塊 (包含)",
+                        "name": "fromBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "結束區塊 (包含)",
+                        "name": "toBlock",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "起始時間 (包含)，Unix 秒數或 RFC3339",
+                        "name": "fromTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "結束時間 (包含)，Unix 秒數或 RFC3339",
+                        "name": "toTime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "最小值 (包含)",
+                        "name": "minValue",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "最大值 (包含)",
+                        "name": "maxValue",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "歷史值",
+                        "schema": {
+                            "$ref": "#/definitions/api.HistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "查詢參數錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 storage:read 權限",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過該路由的請求速率，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "內部錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "未啟用事件索引 (indexing_disabled)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/storage/value": {
+            "get": {
+                "description": "從智能合約中獲取存儲的值，預設讀取最新區塊，block 可以指定區塊號、區塊哈希或標籤，返回實際讀取的區塊號和哈希\n讀取較舊區塊需要歸檔節點，節點已裁剪該區塊的狀態時返回 422",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
                 "tags": [
                     "storage"
                 ],
                 "summary": "獲取存儲的值",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "latest",
+                        "description": "讀取的區塊：區塊號、區塊哈希或 latest、pending、safe、finalized、earliest",
+                        "name": "block",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "成功返回存儲的值",
+                        "description": "成功返回存儲的值，pending 時沒有 blockHash",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "blockHash": {
+                                    "type": "string"
+                                },
+                                "blockNumber": {
+                                    "type": "integer"
+                                },
                                 "value": {
                                     "type": "string"
                                 }
                             }
                         }
                     },
+                    "400": {
+                        "description": "block 參數格式錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 storage:read 權限",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "區塊不存在、不在主鏈上 (block_not_found)，或合約在該區塊時還沒有部署 (contract_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "節點沒有該區塊的狀態，需要歸檔節點 (state_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過該路由的請求速率，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
                     "500": {
                         "description": "內部錯誤",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "error": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "無法連接 RPC 節點 (rpc_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "RPC 節點沒有在超時前返回 (timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             },
             "post": {
-                "description": "在智能合約中設置新的值",
+                "description": "在智能合約中設置新的值。發送前先以發送帳戶在 pending 區塊上預執行，會回滾的交易不發送並返回 422 與解碼後的原因\n預設發送交易後立即返回 202 與交易哈希，可透過 /tx/{hash} 查詢狀態；加上 wait=true 則等待交易被確認後才返回\ndryRun=true 時只返回預執行結果和 gas 估算，不發送交易",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/problem+json"
                 ],
                 "tags": [
                     "storage"
@@ -74,58 +466,832 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.SetValueRequest"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否等待交易被確認",
+                        "name": "wait",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "重試時帶上相同的值，已發送交易的請求不會再次發送，而是返回之前的結果",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "成功設置值",
+                        "description": "交易已確認；dryRun=true 時為 contracts.Simulation",
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "blockNumber": {
+                                    "type": "integer"
+                                },
                                 "message": {
                                     "type": "string"
+                                },
+                                "nonce": {
+                                    "type": "integer"
+                                },
+                                "txHash": {
+                                    "type": "string"
                                 }
                             }
+                        },
+                        "headers": {
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "響應是同一個 Idempotency-Key 之前的結果時為 true"
+                            },
+                            "X-Gas-Budget-Remaining": {
+                                "type": "string",
+                                "description": "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+                            },
+                            "X-Gas-Budget-Reset": {
+                                "type": "string",
+                                "description": "預算重置的時間 (下一個 UTC 零點)"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "交易已發送",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "nonce": {
+                                    "type": "integer"
+                                },
+                                "status": {
+                                    "type": "string"
+                                },
+                                "txHash": {
+                                    "type": "string"
+                                }
+                            }
+                        },
+                        "headers": {
+                            "Idempotent-Replayed": {
+                                "type": "string",
+                                "description": "響應是同一個 Idempotency-Key 之前的結果時為 true"
+                            },
+                            "X-Gas-Budget-Remaining": {
+                                "type": "string",
+                                "description": "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+                            },
+                            "X-Gas-Budget-Reset": {
+                                "type": "string",
+                                "description": "預算重置的時間 (下一個 UTC 零點)"
+                            }
                         }
                     },
                     "400": {
                         "description": "請求格式錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "402": {
+                        "description": "發送帳戶的餘額不足以支付 gas (insufficient_funds)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "nonce 已被使用 (nonce_too_low)、同一 nonce 上已有費用更高的交易 (replacement_underpriced)，或同一個 Idempotency-Key 的請求仍在處理中 (idempotency_in_progress)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "帶 Idempotency-Key 的請求體超過 1 MiB (request_too_large)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "交易預執行時回滾，沒有發送；wait=true 時交易在鏈上回滾，txHash 為該交易 (execution_reverted)；或 Idempotency-Key 已用於不同的請求體或 wait 參數 (idempotency_key_reused)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "內部錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "無法連接外部簽名者 (signer_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "無法連接 RPC 節點 (rpc_unavailable)、節點所在的鏈不符 (wrong_chain)，或所有發送帳戶都暫停使用 (no_sender)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "wait=true 時交易沒有在超時前確認，txHash 為已發送的交易；RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/tx/{hash}": {
+            "get": {
+                "description": "查詢交易是 pending、mined、failed 還是 dropped，以及所在區塊、gas 用量和確認數",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "查詢交易狀態",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "交易哈希",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "交易狀態",
+                        "schema": {
+                            "$ref": "#/definitions/contracts.TxStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "交易哈希格式錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "憑據沒有 storage:read 權限",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "找不到交易 (tx_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "內部錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "無法連接 RPC 節點 (rpc_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "RPC 節點沒有在超時前返回 (timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/tx/{hash}/cancel": {
+            "post": {
+                "description": "在相同的 nonce 上發送一筆 0 ETH 的自我轉帳以取消仍在等待打包的交易",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "取消交易",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "交易哈希",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "取消交易已發送",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "nonce": {
+                                    "type": "integer"
+                                },
+                                "replaces": {
+                                    "type": "string"
+                                },
+                                "txHash": {
                                     "type": "string"
                                 }
                             }
+                        },
+                        "headers": {
+                            "X-Gas-Budget-Remaining": {
+                                "type": "string",
+                                "description": "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+                            },
+                            "X-Gas-Budget-Reset": {
+                                "type": "string",
+                                "description": "預算重置的時間 (下一個 UTC 零點)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "交易哈希格式錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "402": {
+                        "description": "發送帳戶的餘額不足以支付替換交易的費用 (insufficient_funds)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "找不到交易 (tx_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "交易已被打包 (tx_not_pending)、不是由本服務發送 (tx_not_owned)，或節點要求更高的替換費用 (replacement_underpriced)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
                         }
                     },
                     "500": {
                         "description": "內部錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "無法連接外部簽名者 (signer_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "無法連接 RPC 節點 (rpc_unavailable) 或節點所在的鏈不符 (wrong_chain)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/tx/{hash}/speedup": {
+            "post": {
+                "description": "以相同的 nonce 和 calldata、更高的費用重新發送仍在等待打包的交易",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/problem+json"
+                ],
+                "tags": [
+                    "tx"
+                ],
+                "summary": "加速交易",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "交易哈希",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "替換交易已發送",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "error": {
+                                "message": {
+                                    "type": "string"
+                                },
+                                "nonce": {
+                                    "type": "integer"
+                                },
+                                "replaces": {
+                                    "type": "string"
+                                },
+                                "txHash": {
                                     "type": "string"
                                 }
                             }
+                        },
+                        "headers": {
+                            "X-Gas-Budget-Remaining": {
+                                "type": "string",
+                                "description": "當天剩餘的 gas 預算 (wei)，等待中的交易按最高費用預留，沒有設置預算時不返回"
+                            },
+                            "X-Gas-Budget-Reset": {
+                                "type": "string",
+                                "description": "預算重置的時間 (下一個 UTC 零點)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "交易哈希格式錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "缺少或無效的 API 密鑰或 JWT",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "402": {
+                        "description": "發送帳戶的餘額不足以支付替換交易的費用 (insufficient_funds)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "服務以只讀模式運行 (read_only)、外部簽名者拒絕簽名 (signer_rejected)，或憑據沒有 storage:write 權限 (forbidden)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "找不到交易 (tx_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "交易已被打包 (tx_not_pending)、不是由本服務發送 (tx_not_owned)，或節點要求更高的替換費用 (replacement_underpriced)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "超過請求速率 (rate_limited) 或當天剩餘的 gas 預算不足以支付交易的最高費用 (gas_budget_exceeded，此時不發送交易)，Retry-After 為需要等待的秒數",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "內部錯誤",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "無法連接外部簽名者 (signer_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "無法連接 RPC 節點 (rpc_unavailable) 或節點所在的鏈不符 (wrong_chain)",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "RPC 節點 (timeout) 或外部簽名者 (signer_timeout) 沒有在超時前返回",
+                        "schema": {
+                            "$ref": "#/definitions/api.Problem"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ]
             }
         }
     },
     "definitions": {
+        "api.BudgetUsage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "string",
+                    "example": "10000000000000000"
+                },
+                "remaining": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "reserved": {
+                    "type": "string",
+                    "example": "1000000000000000"
+                },
+                "reset": {
+                    "description": "Reset 預算重置的時間 (下一個 UTC 零點)",
+                    "type": "string",
+                    "example": "2024-01-02T00:00:00Z"
+                },
+                "spent": {
+                    "type": "string",
+                    "example": "9000000000000000"
+                }
+            }
+        },
+        "api.HistoryResponse": {
+            "type": "object",
+            "properties": {
+                "complete": {
+                    "description": "Complete 查詢的區間是否已全部索引",
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/indexer.Event"
+                    }
+                },
+                "indexing": {
+                    "description": "Indexing 索引進度，索引尚未追上最新區塊時結果可能不完整",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/indexer.Progress"
+                        }
+                    ]
+                },
+                "nextCursor": {
+                    "description": "NextCursor 下一頁的游標，為空表示沒有更多結果",
+                    "type": "string",
+                    "example": "00000000004fdf8700000000"
+                }
+            }
+        },
+        "api.Problem": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "description": "Budget 超過每日 gas 預算時的用量",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.BudgetUsage"
+                        }
+                    ]
+                },
+                "code": {
+                    "description": "Code 穩定的錯誤碼，客戶端應以它區分錯誤",
+                    "type": "string",
+                    "example": "insufficient_funds"
+                },
+                "detail": {
+                    "description": "Detail 這次錯誤的說明，5xx 錯誤不返回詳細信息，避免洩露 RPC 地址和節點的原始錯誤",
+                    "type": "string",
+                    "example": "failed to set value: insufficient funds for gas and value"
+                },
+                "instance": {
+                    "description": "Instance 出錯的請求路徑",
+                    "type": "string",
+                    "example": "/api/v1/storage/value"
+                },
+                "revert": {
+                    "description": "Revert 交易回滾時解碼後的原因",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/contracts.RevertError"
+                        }
+                    ]
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 402
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Insufficient funds"
+                },
+                "txHash": {
+                    "description": "TxHash 交易已發送後才失敗時的交易哈希，可透過 /tx/{hash} 查詢狀態",
+                    "type": "string",
+                    "example": "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+                },
+                "type": {
+                    "description": "Type 錯誤類型的 URI，由錯誤碼決定",
+                    "type": "string",
+                    "example": "urn:abby:error:insufficient_funds"
+                }
+            }
+        },
         "api.SetValueRequest": {
             "type": "object",
             "required": [
                 "value"
             ],
             "properties": {
+                "dryRun": {
+                    "description": "DryRun 只預執行並返回結果和 gas 估算，不發送交易",
+                    "type": "boolean",
+                    "example": false
+                },
                 "value": {
                     "type": "string",
                     "example": "42"
                 }
             }
+        },
+        "api.StreamMessage": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "$ref": "#/definitions/indexer.Event"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "00000000004fdf8700000000"
+                },
+                "rolledBackTo": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "Type stored 表示新事件，reorg 表示高於 rolledBackTo 的事件因鏈重組失效",
+                    "type": "string",
+                    "enum": [
+                        "stored",
+                        "reorg"
+                    ],
+                    "example": "stored"
+                }
+            }
+        },
+        "contracts.RevertError": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Data 原始回滾數據",
+                    "type": "string",
+                    "example": "0x08c379a0"
+                },
+                "kind": {
+                    "description": "Kind 回滾類型：error、panic、custom 或 unknown",
+                    "type": "string",
+                    "example": "error"
+                },
+                "reason": {
+                    "description": "Reason 解碼後的原因，自定義錯誤格式為 Name(arg, ...)",
+                    "type": "string",
+                    "example": "value too large"
+                }
+            }
+        },
+        "contracts.SenderStats": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+                },
+                "available": {
+                    "description": "Available 帳戶是否參與分配，否則 Reason 說明暫停的原因",
+                    "type": "boolean",
+                    "example": true
+                },
+                "balance": {
+                    "description": "Balance 最近一次檢查時的餘額 (wei)",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lastCheck": {
+                    "type": "string"
+                },
+                "lastSent": {
+                    "type": "string"
+                },
+                "nextNonce": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "pending": {
+                    "description": "Pending 已發送但尚未打包的交易數",
+                    "type": "integer",
+                    "example": 2
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "balance 0.000500 ETH below minimum 0.001000 ETH"
+                },
+                "sent": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "contracts.TxStatus": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer",
+                    "example": 5234567
+                },
+                "confirmations": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "gasUsed": {
+                    "type": "integer",
+                    "example": 26734
+                },
+                "hash": {
+                    "type": "string",
+                    "example": "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+                },
+                "nonce": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "replacedBy": {
+                    "type": "string",
+                    "example": "0x0b6d4f5c3a8e7d2f1e9c8b7a6d5e4f3c2b1a0f9e8d7c6b5a4f3e2d1c0b9a8f7e"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "mined",
+                        "failed",
+                        "dropped"
+                    ],
+                    "example": "mined"
+                }
+            }
+        },
+        "indexer.Event": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string",
+                    "example": "0x3f1c9a3d0c6b1f6e8b2f2a1d5e4c3b2a19f8e7d6c5b4a39281706f5e4d3c2b1a"
+                },
+                "blockNumber": {
+                    "type": "integer",
+                    "example": 5234567
+                },
+                "logIndex": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "example": 1760000000
+                },
+                "txHash": {
+                    "type": "string",
+                    "example": "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
+        "indexer.Progress": {
+            "type": "object",
+            "properties": {
+                "caughtUp": {
+                    "description": "CaughtUp 是否已索引到最新 (扣除確認數) 的區塊",
+                    "type": "boolean"
+                },
+                "headBlock": {
+                    "description": "HeadBlock 鏈上最新區塊",
+                    "type": "integer"
+                },
+                "indexedBlock": {
+                    "description": "IndexedBlock 已處理完的最高區塊",
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "description": "abby apikey new 生成的 API 密鑰",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "\"Bearer \" 加上 API 密鑰或 HS256 簽名的 JWT",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -137,7 +1303,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api/v1",
 	Schemes:          []string{"http"},
 	Title:            "Simple Storage API",
-	Description:      "這是一個簡單的智能合約 API 服務",
+	Description:      "這是一個簡單的智能合約 API 服務\n錯誤響應使用 RFC 9457 的 application/problem+json 格式，code 欄位為穩定的錯誤碼，客戶端應以它而不是 detail 區分錯誤",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,234 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultTTL 記錄預設的保存時間
+const DefaultTTL = 24 * time.Hour
+
+// purgeInterval 清除過期記錄的間隔
+const purgeInterval = time.Hour
+
+var recordsBucket = []byte("records") // 客戶端|Idempotency-Key -> Record
+
+var (
+	// ErrKeyReused 同一個 Idempotency-Key 已用於不同的請求
+	ErrKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrInProgress 同一個 Idempotency-Key 的請求仍在處理中，還沒有發送交易
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// 記錄的狀態
+const (
+	// StateInProgress 請求正在處理，還沒有發送交易
+	StateInProgress = "in-progress"
+	// StateSent 交易已發送，但請求沒有成功返回，例如等待打包時超時
+	StateSent = "sent"
+	// StateDone 請求已成功返回，保存了完整的響應
+	StateDone = "done"
+)
+
+// Record 一個 Idempotency-Key 對應的請求和結果
+type Record struct {
+	// RequestHash 請求體的 SHA-256，用於拒絕以相同的鍵發送不同的請求
+	RequestHash string `json:"requestHash"`
+	State       string `json:"state"`
+	TxHash      string `json:"txHash,omitempty"`
+	Nonce       uint64 `json:"nonce,omitempty"`
+	// Status 和 Body 為 StateDone 時保存的響應
+	Status    int             `json:"status,omitempty"`
+	Body      json.RawMessage `json:"body,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// Store 基於 bbolt 的 Idempotency-Key 記錄，服務重啟後仍然有效
+type Store struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+// OpenStore 打開或創建記錄文件，記錄在 ttl 之後過期
+// 上一個進程留下的處理中記錄不會再完成，打開時刪除，讓客戶端可以重試
+func OpenStore(path string, ttl time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open idempotency store: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(recordsBucket)
+		if err != nil {
+			return err
+		}
+		var abandoned [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil || record.State == StateInProgress {
+				abandoned = append(abandoned, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range abandoned {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize idempotency store: %v", err)
+	}
+
+	return &Store{db: db, ttl: ttl}, nil
+}
+
+// Close 關閉存儲
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Begin 開始處理 key 對應的請求
+// 沒有記錄或記錄已過期時寫入處理中的記錄並返回 nil；相同的請求已發送交易時返回它的記錄
+// 請求不同時返回 ErrKeyReused，相同的請求仍在處理時返回 ErrInProgress
+func (s *Store) Begin(key, requestHash string) (*Record, error) {
+	var existing *Record
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+		now := time.Now().UTC()
+		if data := bucket.Get([]byte(key)); data != nil {
+			var record Record
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+			if now.Before(record.ExpiresAt) {
+				if record.RequestHash != requestHash {
+					return ErrKeyReused
+				}
+				if record.State == StateInProgress {
+					return ErrInProgress
+				}
+				existing = &record
+				return nil
+			}
+		}
+		return put(bucket, key, Record{
+			RequestHash: requestHash,
+			State:       StateInProgress,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(s.ttl),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Sent 記錄請求已發送交易，之後即使請求失敗也不能再次發送
+func (s *Store) Sent(key string, txHash common.Hash, nonce uint64) error {
+	return s.update(key, func(record *Record) {
+		record.State = StateSent
+		record.TxHash = txHash.Hex()
+		record.Nonce = nonce
+	})
+}
+
+// Finish 保存請求成功返回的響應，之後相同的請求直接返回它
+func (s *Store) Finish(key string, status int, body []byte) error {
+	return s.update(key, func(record *Record) {
+		record.State = StateDone
+		record.Status = status
+		record.Body = body
+	})
+}
+
+// Release 刪除沒有發送交易的記錄，客戶端可以用同一個鍵重試
+func (s *Store) Release(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).Delete([]byte(key))
+	})
+}
+
+// Run 定期清除過期的記錄，直到 ctx 被取消
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.purge(); err != nil {
+			log.Printf("Failed to purge idempotency records: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d expired idempotency records", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge 刪除過期的記錄，返回刪除的數量
+func (s *Store) purge() (int, error) {
+	var expired [][]byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+		now := time.Now()
+		err := bucket.ForEach(func(k, v []byte) error {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil || !now.Before(record.ExpiresAt) {
+				// ForEach 中不能修改 bucket，先複製鍵
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(expired), err
+}
+
+// update 修改 key 的記錄，記錄不存在時不做任何事
+func (s *Store) update(key string, modify func(*Record)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		modify(&record)
+		return put(bucket, key, record)
+	})
+}
+
+func put(bucket *bolt.Bucket, key string, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(key), data)
+}
@@ -0,0 +1,149 @@
+package idempotency
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func openTestStore(t *testing.T, path string, ttl time.Duration) *Store {
+	t.Helper()
+	store, err := OpenStore(path, ttl)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStoreBegin(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	tests := []struct {
+		name string
+		// prepare 在以 "req" 開始 key 之後調用
+		prepare   func(s *Store, key string) error
+		request   string
+		wantState string
+		wantErr   error
+	}{
+		{name: "in progress", request: "req", wantErr: ErrInProgress},
+		{name: "different request", request: "other", wantErr: ErrKeyReused},
+		{
+			name:      "sent",
+			prepare:   func(s *Store, key string) error { return s.Sent(key, txHash, 7) },
+			request:   "req",
+			wantState: StateSent,
+		},
+		{
+			name: "done",
+			prepare: func(s *Store, key string) error {
+				if err := s.Sent(key, txHash, 7); err != nil {
+					return err
+				}
+				return s.Finish(key, 202, []byte(`{"ok":true}`))
+			},
+			request:   "req",
+			wantState: StateDone,
+		},
+		{
+			name: "sent but different request",
+			prepare: func(s *Store, key string) error {
+				return s.Sent(key, txHash, 7)
+			},
+			request: "other",
+			wantErr: ErrKeyReused,
+		},
+		{
+			name:    "released",
+			prepare: func(s *Store, key string) error { return s.Release(key) },
+			request: "other",
+		},
+	}
+	store := openTestStore(t, filepath.Join(t.TempDir(), "idempotency.db"), time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "ip:127.0.0.1 " + tt.name
+			if record, err := store.Begin(key, "req"); err != nil || record != nil {
+				t.Fatalf("first Begin = %v, %v, want a new record", record, err)
+			}
+			if tt.prepare != nil {
+				if err := tt.prepare(store, key); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			record, err := store.Begin(key, tt.request)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			if tt.wantState == "" {
+				if record != nil {
+					t.Fatalf("record = %+v, want a new record", record)
+				}
+				return
+			}
+			if record == nil || record.State != tt.wantState || record.TxHash != txHash.Hex() || record.Nonce != 7 {
+				t.Fatalf("record = %+v, want state %s with the sent transaction", record, tt.wantState)
+			}
+			if tt.wantState == StateDone && (record.Status != 202 || string(record.Body) != `{"ok":true}`) {
+				t.Fatalf("saved response = %d %s", record.Status, record.Body)
+			}
+		})
+	}
+}
+
+func TestStoreExpiry(t *testing.T) {
+	store := openTestStore(t, filepath.Join(t.TempDir(), "idempotency.db"), 50*time.Millisecond)
+	if _, err := store.Begin("key", "req"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Sent("key", common.HexToHash("0x01"), 0); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// 過期的記錄不再返回，同一個鍵可以用於新的請求
+	if record, err := store.Begin("key", "other"); err != nil || record != nil {
+		t.Fatalf("Begin after expiry = %v, %v, want a new record", record, err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n, err := store.purge(); err != nil || n != 1 {
+		t.Fatalf("purge = %d, %v, want 1 expired record", n, err)
+	}
+}
+
+func TestOpenStoreDropsInProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.db")
+	store, err := OpenStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin("abandoned", "req"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Begin("sent", "req"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Sent("sent", common.HexToHash("0x01"), 0); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// 上一個進程留下的處理中記錄被刪除，已發送交易的記錄保留
+	store = openTestStore(t, path, time.Hour)
+	if record, err := store.Begin("abandoned", "req"); err != nil || record != nil {
+		t.Fatalf("abandoned key: %v, %v, want a new record", record, err)
+	}
+	if record, err := store.Begin("sent", "req"); err != nil || record == nil || record.State != StateSent {
+		t.Fatalf("sent key: %+v, %v, want the sent record", record, err)
+	}
+}
@@ -0,0 +1,93 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"sync"
+)
+
+// 訂閱者的緩衝區大小，緩衝區滿時訂閱會被關閉，客戶端需要用 Last-Event-ID 重新連接
+const subscriptionBuffer = 256
+
+// 推送消息的類型
+const (
+	MessageStored = "stored"
+	MessageReorg  = "reorg"
+)
+
+// Message 推送給訂閱者的消息
+type Message struct {
+	Type string
+	// Event 新索引的事件，Type 為 MessageStored 時有效
+	Event *Event
+	// RolledBackTo 重組後回滾到的區塊，高於它的事件已失效，Type 為 MessageReorg 時有效
+	RolledBackTo uint64
+}
+
+// ID 事件的唯一標識，按區塊高度和日誌序號遞增，可用作分頁游標和 SSE 的事件 ID
+func (e Event) ID() string {
+	return hex.EncodeToString(eventKey(e.BlockNumber, e.LogIndex))
+}
+
+// ValidEventID 檢查字符串是否為合法的事件 ID
+func ValidEventID(id string) bool {
+	key, err := hex.DecodeString(id)
+	return err == nil && len(key) == len(eventKey(0, 0))
+}
+
+// feed 將索引器處理的事件分發給所有訂閱者
+type feed struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func newFeed() *feed {
+	return &feed{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+func (f *feed) subscribe() *Subscription {
+	sub := &Subscription{
+		ch:   make(chan Message, subscriptionBuffer),
+		feed: f,
+	}
+	f.mu.Lock()
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+	return sub
+}
+
+// send 向所有訂閱者發送消息，跟不上的訂閱者會被移除，避免拖慢索引
+func (f *feed) send(msg Message) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		select {
+		case sub.ch <- msg:
+		default:
+			delete(f.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscription 對新事件的訂閱
+type Subscription struct {
+	ch   chan Message
+	feed *feed
+}
+
+// C 返回接收消息的 channel，訂閱被關閉 (包括因為跟不上被移除) 時 channel 會被關閉
+func (s *Subscription) C() <-chan Message {
+	return s.ch
+}
+
+// Close 取消訂閱
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	if _, ok := s.feed.subs[s]; ok {
+		delete(s.feed.subs, s)
+		close(s.ch)
+	}
+}
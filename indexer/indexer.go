@@ -0,0 +1,312 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errReorgDuringBatch 拉取一批事件期間發生了重組，需要重試
+var errReorgDuringBatch = errors.New("chain reorganized while fetching events")
+
+// Backend 索引器所需的鏈上接口
+type Backend interface {
+	bind.ContractFilterer
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Options 索引器的選項
+type Options struct {
+	// StartBlock 沒有 checkpoint 時開始索引的區塊，通常為合約部署所在區塊
+	StartBlock uint64
+	// Confirmations 只索引至少有這麼多確認數的區塊，0 表示索引到最新區塊
+	Confirmations uint64
+	// BatchSize 每次 FilterLogs 查詢的最大區塊數
+	BatchSize uint64
+	// PollInterval 追上最新區塊後輪詢新區塊的間隔
+	PollInterval time.Duration
+	// ReorgDepth 發生重組時向前查找共同祖先的最大已記錄區塊數
+	ReorgDepth int
+}
+
+// DefaultOptions 返回預設選項
+func DefaultOptions() Options {
+	return Options{
+		BatchSize:    2000,
+		PollInterval: 5 * time.Second,
+		ReorgDepth:   128,
+	}
+}
+
+// Indexer 將合約的 DataStored 事件持久化到 Store，支持斷點續傳和鏈重組回滾
+type Indexer struct {
+	backend  Backend
+	filterer *contracts.ContractsFilterer
+	store    *Store
+	opts     Options
+	feed     *feed
+
+	mu   sync.RWMutex
+	head uint64 // 最近一次觀察到的鏈上最新區塊
+}
+
+// New 創建新的索引器
+func New(backend Backend, contractAddress common.Address, store *Store, opts Options) (*Indexer, error) {
+	filterer, err := contracts.NewContractsFilterer(contractAddress, backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contract filterer: %v", err)
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = DefaultOptions().BatchSize
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultOptions().PollInterval
+	}
+	if opts.ReorgDepth <= 0 {
+		opts.ReorgDepth = DefaultOptions().ReorgDepth
+	}
+
+	return &Indexer{
+		backend:  backend,
+		filterer: filterer,
+		store:    store,
+		opts:     opts,
+		feed:     newFeed(),
+	}, nil
+}
+
+// Subscribe 訂閱之後新索引的事件和重組通知
+func (ix *Indexer) Subscribe() *Subscription {
+	return ix.feed.subscribe()
+}
+
+// Store 返回索引器使用的存儲
+func (ix *Indexer) Store() *Store {
+	return ix.store
+}
+
+// Head 返回最近一次觀察到的鏈上最新區塊
+func (ix *Indexer) Head() uint64 {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.head
+}
+
+// Progress 索引進度
+type Progress struct {
+	// IndexedBlock 已處理完的最高區塊
+	IndexedBlock uint64 `json:"indexedBlock"`
+	// HeadBlock 鏈上最新區塊
+	HeadBlock uint64 `json:"headBlock"`
+	// CaughtUp 是否已索引到最新 (扣除確認數) 的區塊
+	CaughtUp bool `json:"caughtUp"`
+}
+
+// Progress 返回當前的索引進度
+func (ix *Indexer) Progress() (*Progress, error) {
+	checkpoint, err := ix.store.Checkpoint()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+
+	progress := &Progress{HeadBlock: ix.Head()}
+	if checkpoint != nil {
+		progress.IndexedBlock = checkpoint.BlockNumber
+		progress.CaughtUp = checkpoint.BlockNumber+ix.opts.Confirmations >= progress.HeadBlock
+	}
+	return progress, nil
+}
+
+// Run 持續索引直到 ctx 被取消
+func (ix *Indexer) Run(ctx context.Context) error {
+	for {
+		caughtUp, err := ix.step(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Indexer: %v", err)
+		}
+
+		// 還沒追上最新區塊時立即處理下一批
+		wait := ix.opts.PollInterval
+		if err == nil && !caughtUp {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// step 檢查重組並處理一批區塊，返回是否已追上最新區塊
+func (ix *Indexer) step(ctx context.Context) (bool, error) {
+	head, err := ix.backend.BlockNumber(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get block number: %v", err)
+	}
+	ix.mu.Lock()
+	ix.head = head
+	ix.mu.Unlock()
+
+	if head < ix.opts.Confirmations {
+		return true, nil
+	}
+	target := head - ix.opts.Confirmations
+
+	checkpoint, err := ix.store.Checkpoint()
+	if err != nil {
+		return false, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	if checkpoint != nil {
+		if checkpoint, err = ix.handleReorg(ctx, checkpoint); err != nil {
+			return false, err
+		}
+	}
+
+	from := ix.opts.StartBlock
+	if checkpoint != nil {
+		from = checkpoint.BlockNumber + 1
+	}
+	if from > target {
+		return true, nil
+	}
+	to := from + ix.opts.BatchSize - 1
+	if to > target {
+		to = target
+	}
+
+	if err := ix.indexRange(ctx, from, to); err != nil {
+		return false, err
+	}
+	return to == target, nil
+}
+
+// indexRange 索引 [from, to] 區間內的事件並推進 checkpoint
+func (ix *Indexer) indexRange(ctx context.Context, from, to uint64) error {
+	// 先取得區間末端的區塊頭，之後用它校驗事件所在區塊是否仍在同一條鏈上
+	toHeader, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+	if err != nil {
+		return fmt.Errorf("failed to get header %d: %v", to, err)
+	}
+
+	iter, err := ix.filterer.FilterDataStored(&bind.FilterOpts{
+		Start:   from,
+		End:     &to,
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter events: %v", err)
+	}
+	defer iter.Close()
+
+	var events []Event
+	headers := map[uint64]*types.Header{to: toHeader}
+	for iter.Next() {
+		raw := iter.Event.Raw
+		header, ok := headers[raw.BlockNumber]
+		if !ok {
+			header, err = ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(raw.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("failed to get header %d: %v", raw.BlockNumber, err)
+			}
+			headers[raw.BlockNumber] = header
+		}
+		if header.Hash() != raw.BlockHash {
+			return errReorgDuringBatch
+		}
+
+		events = append(events, Event{
+			BlockNumber: raw.BlockNumber,
+			BlockHash:   raw.BlockHash,
+			Timestamp:   header.Time,
+			TxHash:      raw.TxHash,
+			LogIndex:    raw.Index,
+			Value:       iter.Event.NewValue.String(),
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("failed to iterate events: %v", err)
+	}
+
+	if err := ix.store.Apply(events, Checkpoint{BlockNumber: to, BlockHash: toHeader.Hash()}); err != nil {
+		return fmt.Errorf("failed to store events: %v", err)
+	}
+	if len(events) > 0 {
+		log.Printf("Indexer: stored %d events from blocks %d-%d", len(events), from, to)
+	}
+	for i := range events {
+		ix.feed.send(Message{Type: MessageStored, Event: &events[i]})
+	}
+	return nil
+}
+
+// handleReorg 檢查 checkpoint 是否仍在規範鏈上，若已被重組則回滾到共同祖先
+func (ix *Indexer) handleReorg(ctx context.Context, checkpoint *Checkpoint) (*Checkpoint, error) {
+	canonical, err := ix.canonicalHash(ctx, checkpoint.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if canonical == checkpoint.BlockHash {
+		return checkpoint, nil
+	}
+
+	// 從已記錄的區塊中找到仍在規範鏈上的最高區塊
+	blocks, err := ix.store.BlockHashes(checkpoint.BlockNumber, ix.opts.ReorgDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read block hashes: %v", err)
+	}
+	for _, block := range blocks {
+		canonical, err := ix.canonicalHash(ctx, block.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if canonical == block.BlockHash {
+			removed, err := ix.store.Rollback(block)
+			if err != nil {
+				return nil, fmt.Errorf("failed to roll back events: %v", err)
+			}
+			log.Printf("Indexer: reorg detected at block %d, rolled back to %d and removed %d events", checkpoint.BlockNumber, block.BlockNumber, removed)
+			ix.feed.send(Message{Type: MessageReorg, RolledBackTo: block.BlockNumber})
+			return &block, nil
+		}
+	}
+
+	// 找不到共同祖先，從起始區塊重新索引
+	if err := ix.store.Reset(); err != nil {
+		return nil, fmt.Errorf("failed to reset event store: %v", err)
+	}
+	log.Printf("Indexer: reorg deeper than %d indexed blocks at block %d, reindexing from %d", ix.opts.ReorgDepth, checkpoint.BlockNumber, ix.opts.StartBlock)
+	rolledBackTo := uint64(0)
+	if ix.opts.StartBlock > 0 {
+		rolledBackTo = ix.opts.StartBlock - 1
+	}
+	ix.feed.send(Message{Type: MessageReorg, RolledBackTo: rolledBackTo})
+	return nil, nil
+}
+
+// canonicalHash 返回規範鏈上指定高度的區塊哈希，鏈變短導致該高度不存在時返回空哈希
+func (ix *Indexer) canonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	header, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if errors.Is(err, ethereum.NotFound) {
+		return common.Hash{}, nil
+	}
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get header %d: %v", number, err)
+	}
+	return header.Hash(), nil
+}
@@ -0,0 +1,188 @@
+package indexer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestReorgRollsBackAndReindexes(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	alloc := make(types.GenesisAlloc)
+	for i := 0; i < 2; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	backend := simulated.NewBackend(alloc)
+	defer backend.Close()
+	// 創世區塊不是 post-merge 區塊，先出一個空塊，合約字節碼使用了 PUSH0
+	backend.Commit()
+	client := backend.Client()
+	ctx := context.Background()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactors := make([]*bind.TransactOpts, len(keys))
+	for i, key := range keys {
+		if transactors[i], err = bind.NewKeyedTransactorWithChainID(key, chainID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	address, _, contract, err := contracts.DeployContracts(transactors[0], client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	set := func(from int, value int64) {
+		t.Helper()
+		if _, err := contract.Set(transactors[from], big.NewInt(value)); err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+	}
+
+	// 區塊 3 為分叉點，區塊 4 和 5 的事件之後會被重組掉
+	set(0, 1)
+	forkPoint, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set(0, 2)
+	set(0, 3)
+
+	store, err := OpenStore(filepath.Join(t.TempDir(), "events.db"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ix, err := New(client, address, store, Options{StartBlock: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := ix.Subscribe()
+	defer sub.Close()
+
+	catchUp(t, ix)
+	orphaned := storedEvents(t, store)
+	if len(orphaned) != 3 {
+		t.Fatalf("indexed %d events before the reorg, want 3", len(orphaned))
+	}
+	checkpoint, err := store.Checkpoint()
+	if err != nil || checkpoint == nil || checkpoint.BlockNumber != 5 {
+		t.Fatalf("checkpoint = %+v, %v, want block 5", checkpoint, err)
+	}
+
+	// 回到區塊 3，在新的分支上寫入不同的值，並讓新分支比舊分支更長
+	if err := backend.Fork(forkPoint.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	set(1, 4)
+	backend.Commit()
+	backend.Commit()
+
+	rolledBack, err := ix.handleReorg(ctx, checkpoint)
+	if err != nil {
+		t.Fatalf("handleReorg: %v", err)
+	}
+	if rolledBack == nil || rolledBack.BlockNumber != forkPoint.Number.Uint64() || rolledBack.BlockHash != forkPoint.Hash() {
+		t.Fatalf("rolled back to %+v, want block %d %s", rolledBack, forkPoint.Number, forkPoint.Hash().Hex())
+	}
+	if stored, err := store.Checkpoint(); err != nil || *stored != *rolledBack {
+		t.Fatalf("stored checkpoint = %+v, %v, want %+v", stored, err, rolledBack)
+	}
+	if events := storedEvents(t, store); len(events) != 1 || events[0].BlockHash != forkPoint.Hash() {
+		t.Fatalf("events after rollback = %+v, want only the event in block 3", events)
+	}
+	// 之前索引的事件也在訂閱中，找到其後的重組通知
+	var reorg *Message
+	for reorg == nil {
+		select {
+		case msg := <-sub.C():
+			if msg.Type == MessageReorg {
+				reorg = &msg
+			}
+		default:
+			t.Fatal("no reorg message was sent")
+		}
+	}
+	if reorg.RolledBackTo != forkPoint.Number.Uint64() {
+		t.Fatalf("reorg message = %+v, want a rollback to block %d", reorg, forkPoint.Number)
+	}
+
+	// 重新索引新分支，結果應與節點上規範鏈的事件一致，且不包含被重組掉的事件
+	catchUp(t, ix)
+	events := storedEvents(t, store)
+	iter, err := contract.FilterDataStored(&bind.FilterOpts{Start: 2, Context: ctx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	var canonical []Event
+	for iter.Next() {
+		canonical = append(canonical, Event{BlockNumber: iter.Event.Raw.BlockNumber, BlockHash: iter.Event.Raw.BlockHash, TxHash: iter.Event.Raw.TxHash, Value: iter.Event.NewValue.String()})
+	}
+	if len(events) != len(canonical) {
+		t.Fatalf("indexed %d events after the reorg, the canonical chain has %d", len(events), len(canonical))
+	}
+	for i, event := range events {
+		want := canonical[i]
+		if event.BlockNumber != want.BlockNumber || event.BlockHash != want.BlockHash || event.TxHash != want.TxHash || event.Value != want.Value {
+			t.Fatalf("event %d = %+v, want %+v", i, event, want)
+		}
+	}
+	orphanedBlocks := map[common.Hash]bool{}
+	for _, event := range orphaned[1:] {
+		orphanedBlocks[event.BlockHash] = true
+	}
+	onNewBranch := false
+	for _, event := range events {
+		if orphanedBlocks[event.BlockHash] {
+			t.Fatalf("orphaned event %+v is still indexed", event)
+		}
+		onNewBranch = onNewBranch || event.Value == "4"
+	}
+	if !onNewBranch {
+		t.Fatal("the event written on the new branch was not indexed")
+	}
+}
+
+// catchUp 反覆處理區塊直到索引追上最新區塊
+func catchUp(t *testing.T, ix *Indexer) {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		caughtUp, err := ix.step(context.Background())
+		if err != nil {
+			t.Fatalf("step: %v", err)
+		}
+		if caughtUp {
+			return
+		}
+	}
+	t.Fatal("indexer did not catch up")
+}
+
+func storedEvents(t *testing.T, store *Store) []Event {
+	t.Helper()
+	page, err := store.Events(Query{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page.Events
+}
@@ -0,0 +1,174 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrInvalidCursor 分頁游標格式錯誤
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query 事件查詢條件，零值表示不限制
+type Query struct {
+	FromBlock *uint64
+	ToBlock   *uint64
+	FromTime  *uint64
+	ToTime    *uint64
+	MinValue  *big.Int
+	MaxValue  *big.Int
+	// Descending 是否按區塊從新到舊排列
+	Descending bool
+	// Cursor 上一頁返回的 NextCursor 或某個事件的 ID，只返回排在它之後的事件，為空表示第一頁
+	Cursor string
+	Limit  int
+}
+
+// Page 一頁查詢結果
+type Page struct {
+	Events []Event
+	// NextCursor 下一頁的游標，為空表示沒有更多結果
+	NextCursor string
+}
+
+// DefaultLimit 未指定 Limit 時每頁返回的事件數
+const DefaultLimit = 50
+
+// Events 按條件分頁查詢已索引的事件
+func (s *Store) Events(q Query) (*Page, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+
+	var after []byte
+	if q.Cursor != "" {
+		if !ValidEventID(q.Cursor) {
+			return nil, ErrInvalidCursor
+		}
+		after, _ = hex.DecodeString(q.Cursor)
+	}
+
+	page := &Page{Events: []Event{}}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(eventsBucket).Cursor()
+
+		var k, v []byte
+		if q.Descending {
+			k, v = seekLast(c, after, q.ToBlock)
+		} else {
+			k, v = seekFirst(c, after, q.FromBlock)
+		}
+
+		for ; k != nil; k, v = next(c, q.Descending) {
+			var event Event
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			if outOfRange(event, q) {
+				break
+			}
+			if !matches(event, q) {
+				continue
+			}
+			if len(page.Events) == q.Limit {
+				// 還有更多結果，游標指向本頁最後一個事件
+				last := page.Events[len(page.Events)-1]
+				page.NextCursor = last.ID()
+				break
+			}
+			page.Events = append(page.Events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// seekFirst 升序查詢的起點：游標之後，或 fromBlock 的第一個事件
+func seekFirst(c *bolt.Cursor, after []byte, fromBlock *uint64) ([]byte, []byte) {
+	if after != nil {
+		k, v := c.Seek(after)
+		if k != nil && bytes.Equal(k, after) {
+			k, v = c.Next()
+		}
+		return k, v
+	}
+	if fromBlock != nil {
+		return c.Seek(blockKey(*fromBlock))
+	}
+	return c.First()
+}
+
+// seekLast 降序查詢的起點：游標之前，或 toBlock 的最後一個事件
+func seekLast(c *bolt.Cursor, before []byte, toBlock *uint64) ([]byte, []byte) {
+	var bound []byte
+	switch {
+	case before != nil:
+		bound = before
+	case toBlock != nil && *toBlock == math.MaxUint64:
+		// toBlock + 1 會溢出為 0，直接從最後一個事件開始
+		return c.Last()
+	case toBlock != nil:
+		bound = blockKey(*toBlock + 1)
+	default:
+		return c.Last()
+	}
+	k, _ := c.Seek(bound)
+	if k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+func next(c *bolt.Cursor, descending bool) ([]byte, []byte) {
+	if descending {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// outOfRange 事件已超出區塊或時間範圍，按當前方向繼續遍歷也不會再有結果
+func outOfRange(event Event, q Query) bool {
+	if q.Descending {
+		return (q.FromBlock != nil && event.BlockNumber < *q.FromBlock) ||
+			(q.FromTime != nil && event.Timestamp < *q.FromTime)
+	}
+	return (q.ToBlock != nil && event.BlockNumber > *q.ToBlock) ||
+		(q.ToTime != nil && event.Timestamp > *q.ToTime)
+}
+
+// matches 事件是否滿足其餘的過濾條件
+func matches(event Event, q Query) bool {
+	if q.FromBlock != nil && event.BlockNumber < *q.FromBlock {
+		return false
+	}
+	if q.ToBlock != nil && event.BlockNumber > *q.ToBlock {
+		return false
+	}
+	if q.FromTime != nil && event.Timestamp < *q.FromTime {
+		return false
+	}
+	if q.ToTime != nil && event.Timestamp > *q.ToTime {
+		return false
+	}
+	if q.MinValue != nil || q.MaxValue != nil {
+		value, ok := new(big.Int).SetString(event.Value, 10)
+		if !ok {
+			return false
+		}
+		if q.MinValue != nil && value.Cmp(q.MinValue) < 0 {
+			return false
+		}
+		if q.MaxValue != nil && value.Cmp(q.MaxValue) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package indexer
+
+import (
+	"math"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestEventsDescendingToMaxBlock(t *testing.T) {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "events.db"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var events []Event
+	for i := uint64(1); i <= 3; i++ {
+		events = append(events, Event{BlockNumber: i, Value: strconv.FormatUint(i, 10)})
+	}
+	if err := store.Apply(events, Checkpoint{BlockNumber: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, toBlock := range []uint64{2, math.MaxUint64 - 1, math.MaxUint64} {
+		page, err := store.Events(Query{ToBlock: &toBlock, Descending: true, Limit: 10})
+		if err != nil {
+			t.Fatalf("toBlock %d: %v", toBlock, err)
+		}
+		want := min(toBlock, 3)
+		if len(page.Events) != int(want) || page.Events[0].BlockNumber != want {
+			t.Fatalf("toBlock %d: events = %+v, want blocks %d down to 1", toBlock, page.Events, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	eventsBucket = []byte("events") // blockNumber|logIndex -> Event
+	blocksBucket = []byte("blocks") // blockNumber -> blockHash，記錄包含事件的區塊和每批的最後一個區塊
+	metaBucket   = []byte("meta")
+
+	checkpointKey = []byte("checkpoint")
+	sourceKey     = []byte("source")
+)
+
+// Event 已索引的 DataStored 事件
+type Event struct {
+	BlockNumber uint64      `json:"blockNumber" example:"5234567"`
+	BlockHash   common.Hash `json:"blockHash" swaggertype:"string" example:"0x3f1c9a3d0c6b1f6e8b2f2a1d5e4c3b2a19f8e7d6c5b4a39281706f5e4d3c2b1a"`
+	Timestamp   uint64      `json:"timestamp" example:"1760000000"`
+	TxHash      common.Hash `json:"txHash" swaggertype:"string" example:"0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"`
+	LogIndex    uint        `json:"logIndex" example:"0"`
+	Value       string      `json:"value" example:"42"`
+}
+
+// Checkpoint 最後處理完的區塊
+type Checkpoint struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+}
+
+// Store 基於 bbolt 的事件存儲
+type Store struct {
+	db *bolt.DB
+}
+
+// OpenStore 打開或創建事件存儲
+// source 標識事件來源 (鏈 ID 和合約地址)，與已存儲的來源不同時會清空舊數據
+func OpenStore(path string, source string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event store: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		if stored := meta.Get(sourceKey); stored != nil && string(stored) != source {
+			// 換了鏈或合約，舊的事件不再有效
+			for _, name := range [][]byte{eventsBucket, blocksBucket} {
+				if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+					return err
+				}
+			}
+			if err := meta.Delete(checkpointKey); err != nil {
+				return err
+			}
+		}
+		if err := meta.Put(sourceKey, []byte(source)); err != nil {
+			return err
+		}
+		for _, name := range [][]byte{eventsBucket, blocksBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize event store: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close 關閉存儲
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Checkpoint 返回最後處理完的區塊，尚未處理任何區塊時返回 nil
+func (s *Store) Checkpoint() (*Checkpoint, error) {
+	var checkpoint *Checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metaBucket).Get(checkpointKey)
+		if data == nil {
+			return nil
+		}
+		checkpoint = new(Checkpoint)
+		return json.Unmarshal(data, checkpoint)
+	})
+	return checkpoint, err
+}
+
+// Apply 在同一個事務中寫入一批事件並推進 checkpoint
+func (s *Store) Apply(events []Event, checkpoint Checkpoint) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		eventBucket := tx.Bucket(eventsBucket)
+		blockBucket := tx.Bucket(blocksBucket)
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if err := eventBucket.Put(eventKey(event.BlockNumber, event.LogIndex), data); err != nil {
+				return err
+			}
+			if err := blockBucket.Put(blockKey(event.BlockNumber), event.BlockHash.Bytes()); err != nil {
+				return err
+			}
+		}
+		// checkpoint 區塊也記錄哈希，重組時可以回滾到最近的一批而不是更早的事件區塊
+		if err := blockBucket.Put(blockKey(checkpoint.BlockNumber), checkpoint.BlockHash.Bytes()); err != nil {
+			return err
+		}
+		return putCheckpoint(tx, checkpoint)
+	})
+}
+
+// BlockHashes 返回不高於 number 的已記錄區塊哈希，按區塊高度從高到低排列
+func (s *Store) BlockHashes(number uint64, limit int) ([]Checkpoint, error) {
+	var blocks []Checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(blocksBucket).Cursor()
+		k, v := c.Seek(blockKey(number + 1))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && len(blocks) < limit; k, v = c.Prev() {
+			blocks = append(blocks, Checkpoint{
+				BlockNumber: binary.BigEndian.Uint64(k),
+				BlockHash:   common.BytesToHash(v),
+			})
+		}
+		return nil
+	})
+	return blocks, err
+}
+
+// Rollback 刪除高於 checkpoint 的所有事件，並將 checkpoint 退回，返回刪除的事件數
+func (s *Store) Rollback(checkpoint Checkpoint) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		if removed, err = deleteFrom(tx.Bucket(eventsBucket), checkpoint.BlockNumber+1); err != nil {
+			return err
+		}
+		if _, err = deleteFrom(tx.Bucket(blocksBucket), checkpoint.BlockNumber+1); err != nil {
+			return err
+		}
+		return putCheckpoint(tx, checkpoint)
+	})
+	return removed, err
+}
+
+// deleteFrom 刪除區塊高度不低於 blockNumber 的所有鍵
+func deleteFrom(bucket *bolt.Bucket, blockNumber uint64) (int, error) {
+	// 先收集再刪除，避免在遍歷中刪除導致游標跳過元素
+	var keys [][]byte
+	c := bucket.Cursor()
+	for k, _ := c.Seek(blockKey(blockNumber)); k != nil; k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		if err := bucket.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// Reset 清除 checkpoint 和所有事件，下次從起始區塊重新索引
+func (s *Store) Reset() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{eventsBucket, blocksBucket} {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(metaBucket).Delete(checkpointKey)
+	})
+}
+
+func putCheckpoint(tx *bolt.Tx, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(metaBucket).Put(checkpointKey, data)
+}
+
+// eventKey 按區塊高度和日誌序號排序的鍵
+func eventKey(blockNumber uint64, logIndex uint) []byte {
+	key := make([]byte, 12)
+	binary.BigEndian.PutUint64(key, blockNumber)
+	binary.BigEndian.PutUint32(key[8:], uint32(logIndex))
+	return key
+}
+
+func blockKey(blockNumber uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, blockNumber)
+	return key
+}
@@ -0,0 +1,206 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+// settleInterval 查詢等待中交易收據的間隔
+const settleInterval = 10 * time.Second
+
+// ErrBudgetExceeded 客戶端當天的 gas 預算已用完
+var ErrBudgetExceeded = errors.New("daily gas budget exceeded")
+
+// CostSource 查詢交易實際花費的 gas 費用
+type CostSource interface {
+	// TxCost 返回交易收據中的 gasUsed × effectiveGasPrice，done 為 false 表示交易還沒有結果
+	// 交易被替換或丟棄時返回 0 和 true
+	TxCost(ctx context.Context, hash common.Hash) (cost *big.Int, done bool, err error)
+}
+
+// Usage 客戶端當天的預算使用情況，金額以 wei 為單位
+type Usage struct {
+	Limit *big.Int
+	// Spent 已打包交易的實際費用，Reserved 等待中交易按 gas limit × max fee 預留的費用
+	Spent     *big.Int
+	Reserved  *big.Int
+	Remaining *big.Int
+	// Reset 預算重置的時間，即下一個 UTC 零點
+	Reset time.Time
+}
+
+// Budget 每個客戶端每天 (UTC) 的 gas 預算
+// 交易發送前先按最高費用預留，收據出來後改為實際費用
+// NewBudget 創建的預算只保存在內存中，重啟後重置；OpenBudget 創建的預算同時寫入文件
+type Budget struct {
+	limit *big.Int
+	costs CostSource
+	// db 保存花費和預留的文件，為 nil 時只保存在內存中
+	db *bolt.DB
+
+	mu      sync.Mutex
+	clients map[string]*spending
+	pending map[common.Hash]pendingTx
+}
+
+// spending 客戶端某一天的花費
+type spending struct {
+	day      string
+	spent    *big.Int
+	reserved *big.Int
+}
+
+// pendingTx 等待收據的交易
+type pendingTx struct {
+	client   string
+	day      string
+	reserved *big.Int
+}
+
+// NewBudget 創建只保存在內存中的每日預算，limit 為每個客戶端每天可以花費的 wei
+func NewBudget(limit *big.Int, costs CostSource) *Budget {
+	return &Budget{
+		limit:   limit,
+		costs:   costs,
+		clients: make(map[string]*spending),
+		pending: make(map[common.Hash]pendingTx),
+	}
+}
+
+// Check 返回客戶端當天的使用情況，預算已用完時同時返回 ErrBudgetExceeded
+func (b *Budget) Check(client string) (Usage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	usage := b.usage(b.spending(client, time.Now()))
+	if usage.Remaining.Sign() <= 0 {
+		return usage, ErrBudgetExceeded
+	}
+	return usage, nil
+}
+
+// MaxCost 返回交易最多會花費的 gas 費用，即 gas limit × max fee
+func MaxCost(tx *types.Transaction) *big.Int {
+	return new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+}
+
+// TryReserve 在收據出來之前為即將發送的交易預留 maxCost，應在廣播交易之前調用
+// 檢查和預留在同一把鎖下完成，已花費、已預留和 maxCost 之和超過每日預算時不預留並返回 ErrBudgetExceeded
+func (b *Budget) TryReserve(client string, hash common.Hash, maxCost *big.Int) (Usage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.spending(client, time.Now())
+	total := new(big.Int).Add(s.spent, s.reserved)
+	if total.Add(total, maxCost).Cmp(b.limit) > 0 {
+		return b.usage(s), ErrBudgetExceeded
+	}
+	s.reserved.Add(s.reserved, maxCost)
+	b.pending[hash] = pendingTx{client: client, day: s.day, reserved: new(big.Int).Set(maxCost)}
+	if err := b.save(client, hash); err != nil {
+		s.reserved.Sub(s.reserved, maxCost)
+		delete(b.pending, hash)
+		return b.usage(s), fmt.Errorf("failed to save gas budget: %v", err)
+	}
+	return b.usage(s), nil
+}
+
+// Release 撤銷交易的預留，在預留之後交易沒有發送成功時調用
+func (b *Budget) Release(hash common.Hash) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tx, ok := b.pending[hash]
+	if !ok {
+		return
+	}
+	delete(b.pending, hash)
+	if s := b.clients[tx.client]; s != nil && s.day == tx.day {
+		s.reserved.Sub(s.reserved, tx.reserved)
+	}
+	if err := b.save(tx.client, hash); err != nil {
+		log.Printf("Failed to save gas budget after releasing %s: %v", hash.Hex(), err)
+	}
+}
+
+// Run 定期用收據結算等待中的交易，直到 ctx 被取消
+func (b *Budget) Run(ctx context.Context) {
+	ticker := time.NewTicker(settleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		b.settle(ctx)
+	}
+}
+
+// settle 查詢每筆等待中交易的實際費用，把預留的費用換成實際費用
+func (b *Budget) settle(ctx context.Context) {
+	b.mu.Lock()
+	hashes := make([]common.Hash, 0, len(b.pending))
+	for hash := range b.pending {
+		hashes = append(hashes, hash)
+	}
+	b.mu.Unlock()
+
+	for _, hash := range hashes {
+		cost, done, err := b.costs.TxCost(ctx, hash)
+		if err != nil {
+			log.Printf("Failed to settle gas spend of %s: %v", hash.Hex(), err)
+			continue
+		}
+		if !done {
+			continue
+		}
+
+		b.mu.Lock()
+		if tx, ok := b.pending[hash]; ok {
+			delete(b.pending, hash)
+			// 交易發送的那一天已經過去時，當天的記錄已被重置，不計入新的一天
+			if s := b.clients[tx.client]; s != nil && s.day == tx.day {
+				s.reserved.Sub(s.reserved, tx.reserved)
+				s.spent.Add(s.spent, cost)
+			}
+			if err := b.save(tx.client, hash); err != nil {
+				log.Printf("Failed to save gas spend of %s: %v", hash.Hex(), err)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// spending 返回客戶端當天的記錄，跨天時重置，調用時需持有鎖
+func (b *Budget) spending(client string, now time.Time) *spending {
+	day := now.UTC().Format(time.DateOnly)
+	s, ok := b.clients[client]
+	if !ok || s.day != day {
+		s = &spending{day: day, spent: new(big.Int), reserved: new(big.Int)}
+		b.clients[client] = s
+	}
+	return s
+}
+
+func (b *Budget) usage(s *spending) Usage {
+	remaining := new(big.Int).Sub(b.limit, s.spent)
+	remaining.Sub(remaining, s.reserved)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	day, _ := time.Parse(time.DateOnly, s.day)
+	return Usage{
+		Limit:     new(big.Int).Set(b.limit),
+		Spent:     new(big.Int).Set(s.spent),
+		Reserved:  new(big.Int).Set(s.reserved),
+		Remaining: remaining,
+		Reset:     day.AddDate(0, 0, 1),
+	}
+}
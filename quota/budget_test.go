@@ -0,0 +1,132 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBudgetTryReserveConcurrent(t *testing.T) {
+	// 預算只夠 3 筆交易
+	cost := big.NewInt(1000)
+	budget := NewBudget(big.NewInt(3500), nil)
+
+	var ok, exceeded atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := budget.TryReserve("svc-a", common.BigToHash(big.NewInt(int64(i))), cost)
+			switch {
+			case err == nil:
+				ok.Add(1)
+			case errors.Is(err, ErrBudgetExceeded):
+				exceeded.Add(1)
+			default:
+				t.Errorf("TryReserve: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ok.Load() != 3 || exceeded.Load() != 17 {
+		t.Fatalf("%d reserved and %d rejected, want 3 and 17", ok.Load(), exceeded.Load())
+	}
+
+	usage, err := budget.Check("svc-a")
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if usage.Reserved.Int64() != 3000 || usage.Remaining.Int64() != 500 {
+		t.Fatalf("reserved %s remaining %s, want 3000 and 500", usage.Reserved, usage.Remaining)
+	}
+	// 剩餘預算大於 0 但不夠一筆交易時同樣拒絕
+	if _, err := budget.TryReserve("svc-a", common.HexToHash("0xff"), cost); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrBudgetExceeded", err)
+	}
+	// 其他客戶端的預算獨立計算
+	if _, err := budget.TryReserve("svc-b", common.HexToHash("0xff"), cost); err != nil {
+		t.Fatalf("TryReserve for another client: %v", err)
+	}
+}
+
+func TestBudgetRelease(t *testing.T) {
+	budget := NewBudget(big.NewInt(1000), nil)
+	hash := common.HexToHash("0x01")
+	if _, err := budget.TryReserve("svc-a", hash, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := budget.TryReserve("svc-a", common.HexToHash("0x02"), big.NewInt(1)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrBudgetExceeded", err)
+	}
+
+	// 發送失敗撤銷預留之後預算可以再次使用，重複撤銷不影響結果
+	budget.Release(hash)
+	budget.Release(hash)
+	usage, err := budget.Check("svc-a")
+	if err != nil || usage.Reserved.Sign() != 0 || usage.Remaining.Int64() != 1000 {
+		t.Fatalf("after release: %+v, %v", usage, err)
+	}
+	if _, err := budget.TryReserve("svc-a", common.HexToHash("0x02"), big.NewInt(1000)); err != nil {
+		t.Fatalf("TryReserve after release: %v", err)
+	}
+}
+
+// fixedCosts 每筆交易的實際費用都已確定
+type fixedCosts map[common.Hash]*big.Int
+
+func (c fixedCosts) TxCost(ctx context.Context, hash common.Hash) (*big.Int, bool, error) {
+	cost, ok := c[hash]
+	return cost, ok, nil
+}
+
+func TestOpenBudgetPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "budget.db")
+	mined, failed, waiting := common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")
+	costs := fixedCosts{mined: big.NewInt(300)}
+	reopen := func(b *Budget) *Budget {
+		t.Helper()
+		if b != nil {
+			if err := b.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := OpenBudget(path, big.NewInt(5000), costs)
+		if err != nil {
+			t.Fatalf("OpenBudget: %v", err)
+		}
+		t.Cleanup(func() { b.Close() })
+		return b
+	}
+
+	budget := reopen(nil)
+	for _, hash := range []common.Hash{mined, failed, waiting} {
+		if _, err := budget.TryReserve("svc-a", hash, big.NewInt(1000)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	budget.Release(failed)
+
+	// 重啟之後預留還在，收據出來後改為實際費用
+	budget = reopen(budget)
+	usage, _ := budget.Check("svc-a")
+	if usage.Spent.Sign() != 0 || usage.Reserved.Int64() != 2000 {
+		t.Fatalf("after restart spent %s reserved %s, want 0 and 2000", usage.Spent, usage.Reserved)
+	}
+	budget.settle(context.Background())
+
+	budget = reopen(budget)
+	usage, _ = budget.Check("svc-a")
+	if usage.Spent.Int64() != 300 || usage.Reserved.Int64() != 1000 || usage.Remaining.Int64() != 3700 {
+		t.Fatalf("after settling spent %s reserved %s remaining %s, want 300, 1000 and 3700", usage.Spent, usage.Reserved, usage.Remaining)
+	}
+	if _, ok := budget.pending[waiting]; !ok || len(budget.pending) != 1 {
+		t.Fatalf("pending = %v, want only the transaction without a receipt", budget.pending)
+	}
+}
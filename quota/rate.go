@@ -0,0 +1,131 @@
+package quota
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// idleAfter 令牌桶已滿且超過此時間沒有請求時被清除
+const idleAfter = 10 * time.Minute
+
+// Rate 一個路由的令牌桶：每秒補充 Limit 個令牌，最多累積 Burst 個
+type Rate struct {
+	Limit rate.Limit
+	Burst int
+}
+
+// ParseRate 解析 "10/s"、"30/m" 或 "100/h" 格式的速率，burst 為 0 時取每個時間單位的請求數
+func ParseRate(v string, burst int) (Rate, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(v), "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q must look like 10/s, 30/m or 100/h", v)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return Rate{}, fmt.Errorf("rate %q must have a positive request count", v)
+	}
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return Rate{}, fmt.Errorf("rate %q has unknown unit %q, expected s, m or h", v, unit)
+	}
+	if burst < 0 {
+		return Rate{}, fmt.Errorf("burst must not be negative, got %d", burst)
+	}
+	if burst == 0 {
+		burst = max(1, int(n))
+	}
+	return Rate{Limit: rate.Limit(n / per.Seconds()), Burst: burst}, nil
+}
+
+// Decision 一次限流檢查的結果
+type Decision struct {
+	Allowed bool
+	// Limit 令牌桶容量，Remaining 本次請求之後剩餘的令牌數
+	Limit     int
+	Remaining int
+	// RetryAfter 被拒絕時需要等待的時間
+	RetryAfter time.Duration
+}
+
+// RateLimiter 按客戶端和路由分別維護令牌桶，沒有配置速率的路由不限流
+type RateLimiter struct {
+	rates map[string]Rate
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	used    time.Time
+}
+
+// NewRateLimiter 創建限流器，rates 的鍵為 "POST /storage/value" 這樣的方法和路徑
+func NewRateLimiter(rates map[string]Rate) *RateLimiter {
+	return &RateLimiter{
+		rates:   rates,
+		buckets: make(map[string]*bucket),
+		swept:   time.Now(),
+	}
+}
+
+// Allow 為 client 在 route 上的一次請求取一個令牌
+// 路由沒有配置速率時總是允許，ok 為 false
+func (l *RateLimiter) Allow(client, route string) (d Decision, ok bool) {
+	r, ok := l.rates[route]
+	if !ok {
+		return Decision{Allowed: true}, false
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	key := client + " " + route
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{limiter: rate.NewLimiter(r.Limit, r.Burst)}
+		l.buckets[key] = b
+	}
+	b.used = now
+
+	d = Decision{Limit: r.Burst}
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		// 不排隊等待，歸還預約的令牌並告訴客戶端何時重試
+		reservation.CancelAt(now)
+		d.RetryAfter = delay
+		return d, true
+	}
+	d.Allowed = true
+	d.Remaining = int(b.limiter.TokensAt(now))
+	return d, true
+}
+
+// sweep 定期清除長時間沒有使用且已經補滿的令牌桶，避免匿名客戶端的 IP 無限累積
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < idleAfter {
+		return
+	}
+	l.swept = now
+	for key, b := range l.buckets {
+		if now.Sub(b.used) > idleAfter && b.limiter.TokensAt(now) >= float64(b.limiter.Burst()) {
+			delete(l.buckets, key)
+		}
+	}
+}
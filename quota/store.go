@@ -0,0 +1,126 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	spendingBucket = []byte("spending") // 客戶端 -> 當天的花費
+	pendingBucket  = []byte("pending")  // 交易哈希 -> 等待收據的預留
+)
+
+// spendingRecord 文件中保存的 spending
+type spendingRecord struct {
+	Day      string   `json:"day"`
+	Spent    *big.Int `json:"spent"`
+	Reserved *big.Int `json:"reserved"`
+}
+
+// pendingRecord 文件中保存的 pendingTx
+type pendingRecord struct {
+	Client   string   `json:"client"`
+	Day      string   `json:"day"`
+	Reserved *big.Int `json:"reserved"`
+}
+
+// OpenBudget 創建每日預算，花費和預留保存在 path 指向的文件中，服務重啟後仍然有效
+// 打開時刪除已經過去的日子的花費，等待中交易的預留保留到收據出來
+func OpenBudget(path string, limit *big.Int, costs CostSource) (*Budget, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gas budget store: %v", err)
+	}
+
+	b := NewBudget(limit, costs)
+	today := time.Now().UTC().Format(time.DateOnly)
+	err = db.Update(func(tx *bolt.Tx) error {
+		spendings, err := tx.CreateBucketIfNotExists(spendingBucket)
+		if err != nil {
+			return err
+		}
+		pendings, err := tx.CreateBucketIfNotExists(pendingBucket)
+		if err != nil {
+			return err
+		}
+
+		var stale [][]byte
+		err = spendings.ForEach(func(k, v []byte) error {
+			var record spendingRecord
+			if err := json.Unmarshal(v, &record); err != nil || record.Day != today || record.Spent == nil || record.Reserved == nil {
+				// ForEach 中不能修改 bucket，先複製鍵
+				stale = append(stale, append([]byte(nil), k...))
+				return nil
+			}
+			b.clients[string(k)] = &spending{day: record.Day, spent: record.Spent, reserved: record.Reserved}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := spendings.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return pendings.ForEach(func(k, v []byte) error {
+			var record pendingRecord
+			if err := json.Unmarshal(v, &record); err != nil || record.Reserved == nil {
+				return fmt.Errorf("invalid pending reservation %x: %v", k, err)
+			}
+			b.pending[common.BytesToHash(k)] = pendingTx{client: record.Client, day: record.Day, reserved: record.Reserved}
+			return nil
+		})
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load gas budget store: %v", err)
+	}
+
+	b.db = db
+	return b, nil
+}
+
+// Close 關閉保存預算的文件，沒有文件時不做任何事
+func (b *Budget) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Close()
+}
+
+// save 把客戶端當天的花費和 hash 的預留寫入文件，沒有等待中的 hash 時刪除它的記錄
+// 沒有文件時不做任何事，調用時需持有鎖
+func (b *Budget) save(client string, hash common.Hash) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if s, ok := b.clients[client]; ok {
+			data, err := json.Marshal(spendingRecord{Day: s.day, Spent: s.spent, Reserved: s.reserved})
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(spendingBucket).Put([]byte(client), data); err != nil {
+				return err
+			}
+		}
+
+		pendings := tx.Bucket(pendingBucket)
+		p, ok := b.pending[hash]
+		if !ok {
+			return pendings.Delete(hash.Bytes())
+		}
+		data, err := json.Marshal(pendingRecord{Client: p.client, Day: p.day, Reserved: p.reserved})
+		if err != nil {
+			return err
+		}
+		return pendings.Put(hash.Bytes(), data)
+	})
+}
@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Abby/contracts"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DefaultFile 預設的部署記錄文件
+const DefaultFile = "deployments.json"
+
+// SimpleStorage 合約在部署記錄中的名稱
+const SimpleStorage = "SimpleStorage"
+
+// Entry 一個已部署的合約
+type Entry struct {
+	Address     common.Address `json:"address"`
+	TxHash      common.Hash    `json:"txHash,omitzero"`
+	BlockNumber uint64         `json:"blockNumber,omitzero"`
+	Deployer    common.Address `json:"deployer,omitzero"`
+	// ABIHash 部署時合約 ABI 的 keccak256，用於發現合約綁定已更新
+	ABIHash common.Hash `json:"abiHash,omitzero"`
+	// BytecodeHash 部署時創建字節碼的 keccak256
+	BytecodeHash common.Hash `json:"bytecodeHash,omitzero"`
+	DeployedAt   time.Time   `json:"deployedAt,omitzero"`
+}
+
+// Registry 按鏈 ID 和合約名稱記錄部署信息的 JSON 文件
+type Registry struct {
+	path string
+
+	mu sync.Mutex
+	// chains 鏈 ID (十進制字符串) -> 合約名稱 -> 部署信息
+	chains map[string]map[string]Entry
+}
+
+// Open 讀取部署記錄，文件不存在時返回空的記錄
+func Open(path string) (*Registry, error) {
+	r := &Registry{
+		path:   path,
+		chains: make(map[string]map[string]Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deployment registry: %v", err)
+	}
+	if err := json.Unmarshal(data, &r.chains); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment registry %s: %v", path, err)
+	}
+	return r, nil
+}
+
+// Path 返回部署記錄文件的路徑
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Get 返回指定鏈上指定合約的部署信息
+func (r *Registry) Get(chainID uint64, name string) (Entry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.chains[chainKey(chainID)][name]
+	return entry, ok
+}
+
+// Put 記錄部署信息並寫回文件，同一條鏈上的同名合約會被覆蓋
+func (r *Registry) Put(chainID uint64, name string, entry Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := chainKey(chainID)
+	if r.chains[key] == nil {
+		r.chains[key] = make(map[string]Entry)
+	}
+	r.chains[key][name] = entry
+	return r.save()
+}
+
+// save 先寫入臨時文件再重命名，避免寫到一半時留下損壞的記錄
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(r.chains, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write deployment registry: %v", err)
+	}
+	return nil
+}
+
+// NewSimpleStorageEntry 根據已確認的部署創建 SimpleStorage 的部署信息
+func NewSimpleStorageEntry(deployment *contracts.Deployment) Entry {
+	return Entry{
+		Address:      deployment.Address,
+		TxHash:       deployment.Tx.Hash(),
+		BlockNumber:  deployment.Receipt.BlockNumber.Uint64(),
+		Deployer:     deployment.Estimate.From,
+		ABIHash:      ABIHash(),
+		BytecodeHash: crypto.Keccak256Hash(common.FromHex(contracts.ContractsBin)),
+		DeployedAt:   time.Now().UTC(),
+	}
+}
+
+// ABIHash 返回當前合約綁定的 ABI 哈希
+func ABIHash() common.Hash {
+	return crypto.Keccak256Hash([]byte(contracts.ContractsMetaData.ABI))
+}
+
+func chainKey(chainID uint64) string {
+	return strconv.FormatUint(chainID, 10)
+}
+
+// LegacyFile 舊版部署工具寫入合約地址的文件，只包含地址
+const LegacyFile = "contract_address.txt"
+
+// ImportLegacy 在 chainID 沒有 SimpleStorage 記錄時，將 contract_address.txt 中的地址導入部署記錄
+// 舊文件沒有記錄鏈 ID，地址會被歸到當前連接的鏈上
+func (r *Registry) ImportLegacy(chainID uint64) (Entry, bool, error) {
+	data, err := os.ReadFile(LegacyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Entry{}, false, nil
+	}
+	if err != nil {
+		return Entry{}, false, fmt.Errorf("failed to read %s: %v", LegacyFile, err)
+	}
+
+	address := strings.TrimSpace(string(data))
+	if !common.IsHexAddress(address) {
+		return Entry{}, false, fmt.Errorf("%s does not contain a valid address", LegacyFile)
+	}
+
+	entry := Entry{Address: common.HexToAddress(address)}
+	if err := r.Put(chainID, SimpleStorage, entry); err != nil {
+		return Entry{}, false, err
+	}
+	return entry, true, nil
+}
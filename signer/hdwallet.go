@@ -0,0 +1,116 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DefaultHDBasePath BIP-44 以太坊帳戶的基礎路徑，第 i 個帳戶為 m/44'/60'/0'/0/i
+const DefaultHDBasePath = "m/44'/60'/0'/0"
+
+// hardenedKeyStart BIP-32 強化派生的起始索引
+const hardenedKeyStart = 0x80000000
+
+// ErrInvalidMnemonic 助記詞不是有效的 BIP-39 助記詞
+var ErrInvalidMnemonic = errors.New("invalid BIP-39 mnemonic")
+
+// HDWallet 從 BIP-39 助記詞按 BIP-32 路徑派生私鑰
+type HDWallet struct {
+	seed []byte
+}
+
+// NewHDWallet 從助記詞和可選的 BIP-39 密碼創建錢包
+func NewHDWallet(mnemonic, passphrase string) (*HDWallet, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	return &HDWallet{seed: bip39.NewSeed(mnemonic, passphrase)}, nil
+}
+
+// OpenHDWallet 從文件讀取助記詞並創建錢包
+func OpenHDWallet(path, passphrase string) (*HDWallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mnemonic file: %v", err)
+	}
+	wallet, err := NewHDWallet(string(data), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, path)
+	}
+	return wallet, nil
+}
+
+// Derive 按派生路徑返回私鑰
+func (w *HDWallet) Derive(path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+
+	// 主私鑰和鏈碼
+	sum := hmacSHA512([]byte("Bitcoin seed"), w.seed)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errors.New("seed derives an invalid master key")
+	}
+
+	for _, index := range path {
+		var data []byte
+		if index >= hardenedKeyStart {
+			// 強化派生使用父私鑰
+			data = append([]byte{0}, math.PaddedBigBytes(key, 32)...)
+		} else {
+			// 普通派生使用壓縮的父公鑰
+			parent, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		sum := hmacSHA512(chainCode, data)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("path %s derives an invalid key, use another index", path)
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("path %s derives an invalid key, use another index", path)
+		}
+		chainCode = sum[32:]
+	}
+	return crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+}
+
+// Signer 返回使用派生路徑上的私鑰簽名的簽名者
+func (w *HDWallet) Signer(path accounts.DerivationPath) (*KeySigner, error) {
+	key, err := w.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+// HDPath 返回基礎路徑下第 index 個帳戶的派生路徑
+func HDPath(base accounts.DerivationPath, index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(base), len(base)+1)
+	copy(path, base)
+	return append(path, index)
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
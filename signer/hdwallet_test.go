@@ -0,0 +1,64 @@
+package signer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testMnemonic Hardhat 和 Anvil 預設帳戶使用的助記詞
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestHDWalletDerive(t *testing.T) {
+	wallet, err := NewHDWallet(testMnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := accounts.ParseDerivationPath(DefaultHDBasePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path accounts.DerivationPath
+		want common.Address
+	}{
+		{HDPath(base, 0), common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")},
+		{HDPath(base, 1), common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")},
+	}
+	for _, tt := range tests {
+		key, err := wallet.Derive(tt.path)
+		if err != nil {
+			t.Fatalf("Derive(%s): %v", tt.path, err)
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey); got != tt.want {
+			t.Fatalf("Derive(%s) = %s, want %s", tt.path, got.Hex(), tt.want.Hex())
+		}
+	}
+}
+
+func TestHDWalletPaths(t *testing.T) {
+	// 相對路徑接在預設的基礎路徑之後
+	path, err := accounts.ParseDerivationPath("3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path.String() != DefaultHDBasePath+"/3" {
+		t.Fatalf("relative path 3 = %s, want %s/3", path, DefaultHDBasePath)
+	}
+
+	for _, raw := range []string{"", "m/", "m/44'/60'/x", "m/44'/-1", "m/44'/4294967296", "m/44''"} {
+		if path, err := accounts.ParseDerivationPath(raw); err == nil {
+			t.Errorf("path %q parsed as %s, want an error", raw, path)
+		}
+	}
+}
+
+func TestNewHDWalletInvalidMnemonic(t *testing.T) {
+	if _, err := NewHDWallet("test test test test test test test test test test test test", ""); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatalf("err = %v, want ErrInvalidMnemonic", err)
+	}
+}
@@ -0,0 +1,106 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrNoAccounts keystore 目錄中沒有帳戶
+	ErrNoAccounts = errors.New("no accounts in keystore")
+	// ErrAccountNotFound keystore 中找不到指定的帳戶
+	ErrAccountNotFound = errors.New("account not found in keystore")
+	// ErrAmbiguousAccount keystore 中有多個帳戶且沒有指定使用哪一個
+	ErrAmbiguousAccount = errors.New("keystore has several accounts, choose one with signer.account")
+)
+
+// Keystore go-ethereum 格式的加密 keystore 目錄
+type Keystore struct {
+	ks *keystore.KeyStore
+}
+
+// OpenKeystore 打開 keystore 目錄，目錄不存在時會在創建第一個帳戶時建立
+func OpenKeystore(dir string) *Keystore {
+	return &Keystore{
+		ks: keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP),
+	}
+}
+
+// Accounts 返回 keystore 中的所有帳戶
+func (k *Keystore) Accounts() []accounts.Account {
+	return k.ks.Accounts()
+}
+
+// NewAccount 創建新帳戶並以 passphrase 加密保存
+func (k *Keystore) NewAccount(passphrase string) (accounts.Account, error) {
+	account, err := k.ks.NewAccount(passphrase)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to create account: %v", err)
+	}
+	return account, nil
+}
+
+// ImportKey 將私鑰以 passphrase 加密導入 keystore
+func (k *Keystore) ImportKey(key *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	account, err := k.ks.ImportECDSA(key, passphrase)
+	if err != nil {
+		return accounts.Account{}, fmt.Errorf("failed to import key: %v", err)
+	}
+	return account, nil
+}
+
+// Find 返回 address 對應的帳戶，address 為空時返回唯一的帳戶
+func (k *Keystore) Find(address *common.Address) (accounts.Account, error) {
+	all := k.ks.Accounts()
+	if address != nil {
+		for _, account := range all {
+			if account.Address == *address {
+				return account, nil
+			}
+		}
+		return accounts.Account{}, fmt.Errorf("%w: %s", ErrAccountNotFound, address.Hex())
+	}
+
+	switch len(all) {
+	case 0:
+		return accounts.Account{}, ErrNoAccounts
+	case 1:
+		return all[0], nil
+	default:
+		return accounts.Account{}, ErrAmbiguousAccount
+	}
+}
+
+// Unlock 用 passphrase 解鎖帳戶，返回使用該帳戶簽名的簽名者
+// 解密後的私鑰只保存在 keystore 內部
+func (k *Keystore) Unlock(account accounts.Account, passphrase string) (*KeystoreSigner, error) {
+	if err := k.ks.Unlock(account, passphrase); err != nil {
+		if errors.Is(err, keystore.ErrDecrypt) {
+			return nil, fmt.Errorf("failed to unlock %s: wrong passphrase", account.Address.Hex())
+		}
+		return nil, fmt.Errorf("failed to unlock %s: %v", account.Address.Hex(), err)
+	}
+	return &KeystoreSigner{ks: k.ks, account: account}, nil
+}
+
+// KeystoreSigner 使用已解鎖的 keystore 帳戶簽名
+type KeystoreSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+func (s *KeystoreSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *KeystoreSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.ks.SignTx(s.account, tx, chainID)
+}
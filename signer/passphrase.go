@@ -0,0 +1,78 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNoPassphrase 沒有設置密碼且標準輸入不是終端，無法交互式輸入
+var ErrNoPassphrase = errors.New("no keystore passphrase: set signer.passwordFile, KEYSTORE_PASSWORD or run in a terminal")
+
+// PassphraseSource keystore 密碼來源，按 File、Value、交互式輸入的順序使用
+type PassphraseSource struct {
+	// File 密碼文件，只使用第一行
+	File string
+	// Value 直接給出的密碼，例如 KEYSTORE_PASSWORD
+	Value string
+}
+
+// Read 返回解鎖已有帳戶的密碼，交互式輸入時顯示 prompt
+func (p PassphraseSource) Read(prompt string) (string, error) {
+	if p.File != "" {
+		return readPassphraseFile(p.File)
+	}
+	if p.Value != "" {
+		return p.Value, nil
+	}
+	return promptPassphrase(prompt)
+}
+
+// ReadNew 返回加密新帳戶的密碼，交互式輸入時要求輸入兩次並拒絕空密碼
+func (p PassphraseSource) ReadNew(prompt string) (string, error) {
+	if p.File != "" || p.Value != "" {
+		return p.Read(prompt)
+	}
+	passphrase, err := promptPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	confirm, err := promptPassphrase("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func readPassphraseFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %v", err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimRight(line, "\r"), nil
+}
+
+// promptPassphrase 在終端上不回顯地讀取密碼，提示輸出到 stderr 以免混入命令輸出
+func promptPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNoPassphrase
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return string(passphrase), nil
+}
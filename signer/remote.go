@@ -0,0 +1,186 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+var (
+	// ErrRejected 外部簽名者拒絕了簽名請求，例如 Clef 的操作員沒有批准
+	ErrRejected = errors.New("signing request rejected by the external signer")
+	// ErrSignerTimeout 外部簽名者沒有在超時前返回
+	ErrSignerTimeout = errors.New("external signer timed out")
+	// ErrSignerUnavailable 無法連接外部簽名者或它返回了無效的結果
+	ErrSignerUnavailable = errors.New("external signer unavailable")
+)
+
+// Clef 的 JSON-RPC 方法
+const (
+	methodAccountList     = "account_list"
+	methodSignTransaction = "account_signTransaction"
+)
+
+// signTransactionResult account_signTransaction 的返回值
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+// RemoteSigner 通過 JSON-RPC 把交易交給外部簽名者 (與 Clef 兼容) 簽名，進程內不持有私鑰
+type RemoteSigner struct {
+	client   *rpc.Client
+	endpoint string
+	address  common.Address
+	timeout  time.Duration
+}
+
+// DialRemote 連接外部簽名者，每個請求最多等待 timeout
+// account 為 nil 時通過 account_list 查詢，簽名者只能管理一個帳戶
+func DialRemote(ctx context.Context, endpoint string, account *common.Address, timeout time.Duration) (*RemoteSigner, error) {
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to connect to %s: %v", ErrSignerUnavailable, endpoint, err)
+	}
+	s := &RemoteSigner{
+		client:   client,
+		endpoint: endpoint,
+		timeout:  timeout,
+	}
+
+	if account != nil {
+		s.address = *account
+		return s, nil
+	}
+
+	var addresses []common.Address
+	if err := s.call(ctx, &addresses, methodAccountList); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	switch len(addresses) {
+	case 0:
+		client.Close()
+		return nil, fmt.Errorf("%w at %s", ErrNoAccounts, endpoint)
+	case 1:
+		s.address = addresses[0]
+		return s, nil
+	default:
+		client.Close()
+		return nil, fmt.Errorf("external signer has several accounts, choose one with signer.account")
+	}
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx 請求外部簽名者簽名，返回的交易必須由本帳戶簽名且 nonce 不變
+// Clef 允許操作員在批准時修改 gas 等字段，所以其他字段以返回的交易為準
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args, err := sendTxArgs(s.address, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result signTransactionResult
+	if err := s.call(ctx, &result, methodSignTransaction, args); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("%w: invalid signed transaction: %v", ErrSignerUnavailable, err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid signature: %v", ErrSignerUnavailable, err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("%w: transaction signed by %s, expected %s", ErrSignerUnavailable, from.Hex(), s.address.Hex())
+	}
+	if signed.Nonce() != tx.Nonce() {
+		return nil, fmt.Errorf("%w: signer changed the nonce from %d to %d", ErrSignerUnavailable, tx.Nonce(), signed.Nonce())
+	}
+	return signed, nil
+}
+
+// Close 關閉與外部簽名者的連接
+func (s *RemoteSigner) Close() {
+	s.client.Close()
+}
+
+// call 調用外部簽名者，把超時、拒絕和連接錯誤轉換為對應的錯誤
+func (s *RemoteSigner) call(ctx context.Context, result any, method string, args ...any) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	err := s.client.CallContext(ctx, result, method, args...)
+	if err == nil {
+		return nil
+	}
+
+	var rpcErr rpc.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w after %s (%s)", ErrSignerTimeout, s.timeout, method)
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.As(err, &rpcErr) && isRejection(rpcErr):
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	case errors.As(err, &rpcErr):
+		return fmt.Errorf("%s failed: %v", method, err)
+	default:
+		return fmt.Errorf("%w: %v", ErrSignerUnavailable, err)
+	}
+}
+
+// isRejection 判斷簽名者返回的錯誤是否表示請求被拒絕
+// Clef 在操作員拒絕或規則不允許時返回 "Request denied"
+func isRejection(err rpc.Error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "denied") || strings.Contains(msg, "rejected")
+}
+
+// sendTxArgs 把未簽名的交易轉換為 account_signTransaction 的參數
+func sendTxArgs(from common.Address, tx *types.Transaction, chainID *big.Int) (*apitypes.SendTxArgs, error) {
+	data := hexutil.Bytes(tx.Data())
+	var to *common.MixedcaseAddress
+	if tx.To() != nil {
+		t := common.NewMixedcaseAddress(*tx.To())
+		to = &t
+	}
+	args := &apitypes.SendTxArgs{
+		From:    common.NewMixedcaseAddress(from),
+		To:      to,
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+	return args, nil
+}
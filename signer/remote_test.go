@@ -0,0 +1,120 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// startRemote 啟動代替 Clef 的簽名服務，返回它的 URL 和簽名帳戶
+func startRemote(t *testing.T, approve ApproveFunc) (string, common.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewRPCServer(NewKeySigner(key), approve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func testTx(nonce uint64) *types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	return types.NewTx(&types.DynamicFeeTx{
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(2e9),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+		Data:      []byte{0x01, 0x02},
+	})
+}
+
+func TestRemoteSignerSignTx(t *testing.T) {
+	url, account := startRemote(t, nil)
+	ctx := context.Background()
+
+	// 沒有指定帳戶時通過 account_list 查詢
+	remote, err := DialRemote(ctx, url, nil, time.Second)
+	if err != nil {
+		t.Fatalf("DialRemote: %v", err)
+	}
+	defer remote.Close()
+	if remote.Address() != account {
+		t.Fatalf("address = %s, want %s", remote.Address().Hex(), account.Hex())
+	}
+
+	chainID := big.NewInt(11155111)
+	tx := testTx(7)
+	signed, err := remote.SignTx(ctx, tx, chainID)
+	if err != nil {
+		t.Fatalf("SignTx: %v", err)
+	}
+	if signed.ChainId().Cmp(chainID) != 0 {
+		t.Fatalf("chain id = %s, want %s", signed.ChainId(), chainID)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil || from != account {
+		t.Fatalf("sender = %s, %v, want %s", from.Hex(), err, account.Hex())
+	}
+	if signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() || *signed.To() != *tx.To() || signed.Value().Cmp(tx.Value()) != 0 || string(signed.Data()) != string(tx.Data()) {
+		t.Fatalf("signed transaction %+v differs from the request", signed)
+	}
+}
+
+func TestRemoteSignerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejected", func(t *testing.T) {
+		url, account := startRemote(t, func(*apitypes.SendTxArgs) bool { return false })
+		remote, err := DialRemote(ctx, url, &account, time.Second)
+		if err != nil {
+			t.Fatalf("DialRemote: %v", err)
+		}
+		defer remote.Close()
+		if _, err := remote.SignTx(ctx, testTx(0), big.NewInt(1)); !errors.Is(err, ErrRejected) {
+			t.Fatalf("err = %v, want ErrRejected", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		// 模擬操作員遲遲沒有批准
+		url, account := startRemote(t, func(*apitypes.SendTxArgs) bool {
+			time.Sleep(300 * time.Millisecond)
+			return true
+		})
+		remote, err := DialRemote(ctx, url, &account, 50*time.Millisecond)
+		if err != nil {
+			t.Fatalf("DialRemote: %v", err)
+		}
+		defer remote.Close()
+		if _, err := remote.SignTx(ctx, testTx(0), big.NewInt(1)); !errors.Is(err, ErrSignerTimeout) {
+			t.Fatalf("err = %v, want ErrSignerTimeout", err)
+		}
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		// 已經關閉的端點
+		server := httptest.NewServer(nil)
+		server.Close()
+		if _, err := DialRemote(ctx, server.URL, nil, time.Second); !errors.Is(err, ErrSignerUnavailable) {
+			t.Fatalf("err = %v, want ErrSignerUnavailable", err)
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// errRequestDenied 與 Clef 拒絕請求時返回的錯誤相同
+var errRequestDenied = errors.New("Request denied")
+
+// ApproveFunc 決定是否批准一個簽名請求
+type ApproveFunc func(args *apitypes.SendTxArgs) bool
+
+// NewRPCServer 創建與 Clef 兼容的 JSON-RPC 簽名服務，用 s 簽名，供測試外部簽名時代替 Clef
+// 支持 account_list 和 account_signTransaction，approve 返回 false 的請求以 "Request denied" 拒絕
+func NewRPCServer(s Signer, approve ApproveFunc) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &accountAPI{signer: s, approve: approve}); err != nil {
+		return nil, fmt.Errorf("failed to register signer API: %v", err)
+	}
+	return server, nil
+}
+
+// accountAPI Clef 的 account 命名空間
+type accountAPI struct {
+	signer  Signer
+	approve ApproveFunc
+}
+
+// List 返回可用於簽名的帳戶
+func (api *accountAPI) List(ctx context.Context) ([]common.Address, error) {
+	return []common.Address{api.signer.Address()}, nil
+}
+
+// SignTransaction 簽名交易但不發送
+func (api *accountAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, methodSelector *string) (*signTransactionResult, error) {
+	if args.From.Address() != api.signer.Address() {
+		return nil, fmt.Errorf("unknown account %s", args.From.Address().Hex())
+	}
+	if args.ChainID == nil {
+		return nil, errors.New("chainId is required")
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	if api.approve != nil && !api.approve(&args) {
+		log.Printf("Signer: denied transaction from %s (nonce %d)", args.From.Address().Hex(), tx.Nonce())
+		return nil, errRequestDenied
+	}
+
+	signed, err := api.signer.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Signer: signed transaction %s from %s (nonce %d)", signed.Hash().Hex(), args.From.Address().Hex(), signed.Nonce())
+	return &signTransactionResult{Raw: raw, Tx: signed}, nil
+}
@@ -0,0 +1,73 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer 交易簽名者，私鑰只保存在實現內部，不以字符串形式對外傳遞
+type Signer interface {
+	// Address 返回簽名帳戶的地址
+	Address() common.Address
+	// SignTx 為 chainID 上的交易簽名
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// KeySigner 使用內存中的私鑰簽名
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner 從私鑰創建簽名者
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// NewHexKeySigner 從十六進制私鑰 (可帶 0x 前綴) 創建簽名者
+func NewHexKeySigner(hexKey string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(trimHexPrefix(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert private key: %v", err)
+	}
+	return NewKeySigner(key), nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// TransactOpts 創建使用 s 簽名的交易選項
+func TransactOpts(ctx context.Context, s Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:    s.Address(),
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+func trimHexPrefix(s string) string {
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		return s[2:]
+	}
+	return s
+}